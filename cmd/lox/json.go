@@ -0,0 +1,126 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/codecrafters-io/interpreter-starter-go/pkg/lox"
+)
+
+type tokenJSON struct {
+	Type    string      `json:"type"`
+	Lexeme  string      `json:"lexeme"`
+	Literal interface{} `json:"literal"`
+	Line    int         `json:"line"`
+	Column  int         `json:"column"`
+}
+
+func tokenToJSON(token lox.Token) tokenJSON {
+	var literal interface{}
+	switch token.Type {
+	case "NUMBER":
+		n, _ := strconv.ParseFloat(token.Literal.(string), 64)
+		literal = numberToJSON(n)
+	case "STRING":
+		literal = token.Literal
+	}
+	return tokenJSON{
+		Type:    token.Type,
+		Lexeme:  token.Lexeme,
+		Literal: literal,
+		Line:    token.Line,
+		Column:  token.Column,
+	}
+}
+
+type valueJSON struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// valueToJSON tags a runtime value with its Lox type name. Callables and
+// instances are not representable in JSON and are sent as their printed form.
+func valueToJSON(value interface{}) valueJSON {
+	switch v := value.(type) {
+	case nil:
+		return valueJSON{Type: "nil"}
+	case bool:
+		return valueJSON{Type: "boolean", Value: v}
+	case float64:
+		return valueJSON{Type: "number", Value: numberToJSON(v)}
+	case string:
+		return valueJSON{Type: "string", Value: v}
+	case *lox.LoxClass:
+		return valueJSON{Type: "class", Value: lox.Stringify(v)}
+	case *lox.LoxInstance:
+		return valueJSON{Type: "instance", Value: lox.Stringify(v)}
+	case lox.LoxCallable:
+		return valueJSON{Type: "function", Value: lox.Stringify(v)}
+	default:
+		return valueJSON{Type: "unknown", Value: lox.Stringify(v)}
+	}
+}
+
+// numberToJSON passes finite numbers through and spells out the ones JSON
+// has no number for.
+func numberToJSON(n float64) interface{} {
+	switch {
+	case math.IsInf(n, 1):
+		return "Infinity"
+	case math.IsInf(n, -1):
+		return "-Infinity"
+	case math.IsNaN(n):
+		return "NaN"
+	}
+	return n
+}
+
+// nodeToJSON turns an AST node into maps and slices for encoding/json. Each
+// node struct becomes an object whose "type" is the Go type name, with one
+// lowerCamelCase key per field, so new node types need no extra code here.
+func nodeToJSON(node interface{}) interface{} {
+	return reflectToJSON(reflect.ValueOf(node))
+}
+
+func reflectToJSON(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return nodeToJSON(v.Elem().Interface())
+	case reflect.Slice:
+		items := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, reflectToJSON(v.Index(i)))
+		}
+		return items
+	case reflect.Struct:
+		if token, ok := v.Interface().(lox.Token); ok {
+			return map[string]interface{}{"lexeme": token.Lexeme, "line": token.Line, "column": token.Column}
+		}
+		object := map[string]interface{}{"type": v.Type().Name()}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			object[lowerFirst(field.Name)] = reflectToJSON(v.Field(i))
+		}
+		return object
+	case reflect.Float64:
+		return numberToJSON(v.Float())
+	default:
+		return v.Interface()
+	}
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
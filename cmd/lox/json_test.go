@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestValueToJSON(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, `{"type":"nil","value":null}`},
+		{true, `{"type":"boolean","value":true}`},
+		{1.5, `{"type":"number","value":1.5}`},
+		{"hi", `{"type":"string","value":"hi"}`},
+		{math.Inf(1), `{"type":"number","value":"Infinity"}`},
+		{math.Inf(-1), `{"type":"number","value":"-Infinity"}`},
+		{math.NaN(), `{"type":"number","value":"NaN"}`},
+	}
+	for _, test := range tests {
+		got, err := json.Marshal(valueToJSON(test.value))
+		if err != nil {
+			t.Errorf("valueToJSON(%v): %v", test.value, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("valueToJSON(%v) = %s, want %s", test.value, got, test.want)
+		}
+	}
+}
@@ -1,115 +1,201 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
+
+	"github.com/codecrafters-io/interpreter-starter-go/pkg/diagnostics"
+	"github.com/codecrafters-io/interpreter-starter-go/pkg/lox"
 )
 
-const LexicalError = 65
+const (
+	LexicalError = 65
+	RuntimeFault = 70
+)
 
 func main() {
+	if len(os.Args) == 1 || os.Args[1] == "repl" {
+		runRepl(os.Stdin, os.Stdout, os.Stderr)
+		os.Exit(0)
+	}
+
 	if len(os.Args) < 3 {
-		fmt.Fprintln(os.Stderr, "Usage: ./your_program.sh tokenize <filename>")
+		fmt.Fprintln(os.Stderr, "Usage: ./your_program.sh <tokenize|parse|evaluate|run|disassemble> [--format=text|json] [--engine=tree|vm] [--trace] [--stress-gc] <filename>")
+		fmt.Fprintln(os.Stderr, "       ./your_program.sh [repl]")
 		os.Exit(1)
 	}
 
 	command := os.Args[1]
-	if command != "tokenize" && command != "parse" && command != "evaluate" {
+	if command != "tokenize" && command != "parse" && command != "evaluate" && command != "run" && command != "disassemble" {
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", command)
 		os.Exit(1)
 	}
 
-	rawfile, err := os.ReadFile(os.Args[2])
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	format := flags.String("format", "text", "output format, text or json")
+	engineName := flags.String("engine", "tree", "execution engine for run, tree or vm")
+	trace := flags.Bool("trace", false, "print the VM stack and each instruction as run executes")
+	stressGC := flags.Bool("stress-gc", false, "collect garbage on every VM allocation")
+	// Flags may come before or after the filename, but nothing else may
+	// follow it: the flag package stops at the first positional argument,
+	// so parse what is left after the filename a second time.
+	flags.Parse(os.Args[2:])
+	if flags.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "Missing filename")
+		os.Exit(1)
+	}
+	filename := flags.Arg(0)
+	flags.Parse(flags.Args()[1:])
+	if flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Unexpected argument: %s\n", flags.Arg(0))
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Unknown format: %s\n", *format)
+		os.Exit(1)
+	}
+	engine := lox.TreeWalker
+	switch *engineName {
+	case "tree":
+	case "vm":
+		engine = lox.BytecodeVM
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown engine: %s\n", *engineName)
+		os.Exit(1)
+	}
+	asJSON := *format == "json"
+
+	rawfile, err := os.ReadFile(filename)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
 		os.Exit(1)
 	}
 
 	fileContents := string(rawfile)
-	scanner := NewScanner(fileContents)
-	tokens := scanner.ScanTokens()
+	reporter := diagnostics.NewReporter(os.Stderr, fileContents)
+	if asJSON {
+		reporter.Format = diagnostics.JSON
+	}
 
-	switch command {
-	case "tokenize":
-		for _, token := range tokens {
-			switch token.Type {
-			case "EOF":
-				fmt.Printf("%s  %s\n", token.Type, token.Literal)
-			case "STRING":
-				fmt.Printf("%s %s %s\n", token.Type, token.Lexeme, token.Literal)
-			case "NUMBER":
-				fmt.Printf("%s %s %s\n", token.Type, token.Lexeme, token.Literal)
-			case "IDENTIFIER":
-				fmt.Printf("%s %s %s\n", token.Type, token.Lexeme, token.Literal)
-			default:
-				fmt.Printf("%s %s %s\n", token.Type, token.Lexeme, token.Literal)
-			}
-		}
-		if len(scanner.Errors) > 0 {
-			for _, err := range scanner.Errors {
-				fmt.Fprintln(os.Stderr, err)
-			}
+	if command == "disassemble" {
+		interpreter := lox.New(lox.Options{DiagnosticFormat: reporter.Format, Engine: lox.BytecodeVM})
+		function, err := interpreter.Compile(fileContents)
+		if err != nil {
 			os.Exit(LexicalError)
 		}
+		lox.Disassemble(os.Stdout, function)
 		os.Exit(0)
+	}
 
-	case "parse":
-		parser := NewParser(fileContents, tokens)
-		ast, err := parser.Parse()
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			if parserError, ok := err.(*ParserError); ok {
-				fmt.Fprintf(os.Stderr, "Error at line %d: %s\n", parserError.Token.Line, parserError.Message)
-				os.Exit(LexicalError)
-			}
+	if command == "run" {
+		options := lox.Options{DiagnosticFormat: reporter.Format, Engine: engine, StressGC: *stressGC}
+		if (*trace || *stressGC) && engine != lox.BytecodeVM {
+			fmt.Fprintln(os.Stderr, "--trace and --stress-gc require --engine=vm")
 			os.Exit(1)
 		}
-		if scannerError, ok := err.(*ScannerError); ok {
-			fmt.Fprintf(os.Stderr, "Error at line %d: %s\n", scannerError.Line, scannerError.Message)
-			os.Exit(LexicalError)
+		if *trace {
+			options.Trace = os.Stderr
 		}
-		if len(scanner.Errors) > 0 {
-			for _, err := range scanner.Errors {
-				fmt.Fprintln(os.Stderr, err)
+		interpreter := lox.New(options)
+		if err := interpreter.Run(context.Background(), fileContents); err != nil {
+			if loxErr, ok := err.(*lox.Error); ok && loxErr.Kind == lox.CompileError {
+				os.Exit(LexicalError)
 			}
-			os.Exit(LexicalError)
+			os.Exit(RuntimeFault)
 		}
-		for _, node := range ast.Nodes {
-			fmt.Println(node.String())
-		}
-	case "evaluate":
-		parser := NewParser(fileContents, tokens)
-		ast, err := parser.Parse()
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			if parserError, ok := err.(*ParserError); ok {
-				fmt.Fprintf(os.Stderr, "Error at line %d: %s\n", parserError.Token.Line, parserError.Message)
-				os.Exit(LexicalError)
+		os.Exit(0)
+	}
+
+	scanner := lox.NewScanner(fileContents)
+	tokens := scanner.ScanTokens()
+
+	switch command {
+	case "tokenize":
+		if asJSON {
+			out := []tokenJSON{}
+			for _, token := range tokens {
+				out = append(out, tokenToJSON(token))
+			}
+			printJSON(out)
+		} else {
+			for _, token := range tokens {
+				switch token.Type {
+				case "EOF":
+					fmt.Printf("%s  %s\n", token.Type, token.Literal)
+				default:
+					fmt.Printf("%s %s %s\n", token.Type, token.Lexeme, token.Literal)
+				}
 			}
-			os.Exit(1)
 		}
-		if scannerError, ok := err.(*ScannerError); ok {
-			fmt.Fprintf(os.Stderr, "Error at line %d: %s\n", scannerError.Line, scannerError.Message)
-			os.Exit(LexicalError)
+		for _, err := range scanner.Errors {
+			reporter.ReportError(err)
 		}
-		if len(scanner.Errors) > 0 {
-			for _, err := range scanner.Errors {
-				fmt.Fprintln(os.Stderr, err)
+		exitOnErrors(reporter, LexicalError)
+
+	case "parse":
+		ast := parse(reporter, scanner, tokens)
+		if asJSON {
+			out := []interface{}{}
+			for _, node := range ast.Nodes {
+				out = append(out, nodeToJSON(node))
 			}
-			os.Exit(LexicalError)
+			printJSON(out)
+			break
 		}
-		evaluator := NewEvaluator(ast)
+		for _, node := range ast.Nodes {
+			fmt.Println(node.String())
+		}
+
+	case "evaluate":
+		ast := parse(reporter, scanner, tokens)
+		evaluator := lox.NewEvaluator(ast, scanner.Symbols)
 		res, err := evaluator.Evaluate()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			if runtimeError, ok := err.(*RuntimeError); ok {
-				fmt.Fprintf(os.Stderr, "Error at line %d: %s\n", runtimeError.Token.Line, runtimeError.Message)
-				os.Exit(70)
+			reporter.ReportError(err)
+			os.Exit(RuntimeFault)
+		}
+		if asJSON {
+			out := []valueJSON{}
+			for _, r := range res.([]interface{}) {
+				out = append(out, valueToJSON(r))
 			}
-			os.Exit(1)
+			printJSON(out)
+			break
 		}
 		for _, r := range res.([]interface{}) {
-			fmt.Println(formatOutput(r))
+			fmt.Println(lox.Stringify(r))
 		}
 	}
 }
+
+// parse reads the file as a sequence of expressions, reporting every scanner
+// and parser error and exiting if there were any, so callers only ever see a
+// complete AST.
+func parse(reporter *diagnostics.Reporter, scanner *lox.Scanner, tokens []lox.Token) *lox.AST {
+	for _, err := range scanner.Errors {
+		reporter.ReportError(err)
+	}
+	parser := lox.NewParser(scanner.Source, tokens)
+	ast, _ := parser.Parse()
+	for _, err := range parser.Errors {
+		reporter.ReportError(err)
+	}
+	exitOnErrors(reporter, LexicalError)
+	return ast
+}
+
+func printJSON(v interface{}) {
+	if err := json.NewEncoder(os.Stdout).Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func exitOnErrors(reporter *diagnostics.Reporter, code int) {
+	if reporter.Count(diagnostics.Error) > 0 {
+		os.Exit(code)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/codecrafters-io/interpreter-starter-go/pkg/diagnostics"
+	"github.com/codecrafters-io/interpreter-starter-go/pkg/lox"
+)
+
+// runRepl reads Lox source from in one line at a time and executes it
+// against a single interpreter, so globals survive between lines. Input is
+// buffered until its braces and parentheses balance, and errors are reported
+// to errOut without ending the session.
+func runRepl(in io.Reader, out io.Writer, errOut io.Writer) {
+	// The interpreter reads input() from the same buffered reader as the
+	// REPL, so a line typed in answer to input() isn't also run as code.
+	input := bufio.NewReader(in)
+	interpreter := lox.New(lox.Options{Stdin: input, Stdout: out, Stderr: errOut})
+	source := ""
+
+	fmt.Fprint(out, "> ")
+	for {
+		line, err := input.ReadString('\n')
+		if line == "" && err != nil {
+			break
+		}
+		source += strings.TrimRight(line, "\r\n") + "\n"
+
+		scanner := lox.NewScanner(source)
+		tokens := scanner.ScanTokens()
+		if len(scanner.Errors) == 0 && unbalanced(tokens) {
+			fmt.Fprint(out, "... ")
+			continue
+		}
+
+		replExecute(interpreter, scanner, tokens, out, errOut)
+		source = ""
+		fmt.Fprint(out, "> ")
+	}
+	fmt.Fprintln(out)
+}
+
+func replExecute(interpreter *lox.Interpreter, scanner *lox.Scanner, tokens []lox.Token, out io.Writer, errOut io.Writer) {
+	ctx := context.Background()
+	if len(scanner.Errors) > 0 {
+		reporter := diagnostics.NewReporter(errOut, scanner.Source)
+		for _, err := range scanner.Errors {
+			reporter.ReportError(err)
+		}
+		return
+	}
+
+	// A lone expression without a trailing semicolon is echoed back instead
+	// of being rejected as a statement.
+	if _, err := lox.NewParser(scanner.Source, tokens).ParseStatements(); err != nil {
+		exprAST, exprErr := lox.NewParser(scanner.Source, tokens).Parse()
+		if exprErr == nil && len(exprAST.Nodes) == 1 {
+			if value, err := interpreter.Eval(ctx, scanner.Source); err == nil {
+				fmt.Fprintln(out, lox.Stringify(value))
+			}
+			return
+		}
+	}
+
+	// Run reports its own errors to errOut.
+	interpreter.Run(ctx, scanner.Source)
+}
+
+// unbalanced reports whether tokens open more braces or parentheses than
+// they close, meaning the user is still typing a multi-line construct.
+func unbalanced(tokens []lox.Token) bool {
+	depth := 0
+	for _, token := range tokens {
+		switch token.Type {
+		case "LEFT_BRACE", "LEFT_PAREN":
+			depth++
+		case "RIGHT_BRACE", "RIGHT_PAREN":
+			depth--
+		}
+	}
+	return depth > 0
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRepl(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		stdout string
+		stderr string
+	}{
+		{
+			"statement",
+			"print 1 + 2;\n",
+			"> 3\n> \n",
+			"",
+		},
+		{
+			"echoes expressions",
+			"1 + 2\n\"hi\"\nnil\n",
+			"> 3\n> hi\n> nil\n> \n",
+			"",
+		},
+		{
+			"globals persist",
+			"var a = 1;\na = a + 1;\na\n",
+			"> > > 2\n> \n",
+			"",
+		},
+		{
+			"multi-line continuation",
+			"fun f(x) {\n  return x * 2;\n}\nf(\n21\n)\n",
+			"> ... ... > ... ... 42\n> \n",
+			"",
+		},
+		{
+			"errors don't end the session",
+			"print nope;\nprint \"still here\";\n",
+			"> > still here\n> \n",
+			"[line 1] Error at 'nope': Undefined variable 'nope'.\n    1 | print nope;\n      |       ^^^^\n",
+		},
+		{
+			"syntax error",
+			"var = 1;\n1\n",
+			"> > 1\n> \n",
+			"[line 1] Error at '=': Expect variable name.\n    1 | var = 1;\n      |     ^\n",
+		},
+		{
+			"input shares stdin",
+			"var name = input();\nlox\nprint \"hi \" + name;\n",
+			"> > hi lox\n> \n",
+			"",
+		},
+		{
+			"last line without newline",
+			"print 1;",
+			"> 1\n> \n",
+			"",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			runRepl(strings.NewReader(test.input), &stdout, &stderr)
+			if stdout.String() != test.stdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), test.stdout)
+			}
+			if stderr.String() != test.stderr {
+				t.Errorf("stderr = %q, want %q", stderr.String(), test.stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+// Package diagnostics formats errors, warnings and notes produced while
+// scanning, parsing and running Lox code, pointing back at the offending
+// source with a caret underline.
+package diagnostics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Severity ranks how serious a Diagnostic is.
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+	Note
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Warning:
+		return "Warning"
+	case Note:
+		return "Note"
+	default:
+		return "Error"
+	}
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(s.String())), nil
+}
+
+// Format selects how a Reporter writes diagnostics.
+type Format int
+
+const (
+	// Text renders the message followed by a source snippet.
+	Text Format = iota
+	// JSON writes one JSON object per diagnostic, one per line.
+	JSON
+)
+
+// Diagnostic is a single message about a location in the source. Line and
+// Column are 1-based; zero means the position is unknown.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Length   int      `json:"length"`
+	// Token is the lexeme of the offending token, if there is one. AtEnd
+	// is set instead when the error is at the end of the input.
+	Token   string `json:"token,omitempty"`
+	AtEnd   bool   `json:"atEnd,omitempty"`
+	Message string `json:"message"`
+	// Trace lists the calls that were active when a runtime error was
+	// raised, innermost first.
+	Trace []Frame `json:"trace,omitempty"`
+}
+
+// Frame is one entry in a traceback: the line executing in a function when
+// the error was raised or the next call was made. Function is empty for the
+// top-level script.
+type Frame struct {
+	Line     int    `json:"line"`
+	Function string `json:"function"`
+}
+
+func (f Frame) String() string {
+	if f.Function == "" {
+		return fmt.Sprintf("[line %d] in script", f.Line)
+	}
+	return fmt.Sprintf("[line %d] in %s()", f.Line, f.Function)
+}
+
+func (d *Diagnostic) Error() string {
+	if d.Line == 0 {
+		return fmt.Sprintf("%s%s: %s", d.Severity, d.where(), d.Message)
+	}
+	return fmt.Sprintf("[line %d] %s%s: %s", d.Line, d.Severity, d.where(), d.Message)
+}
+
+// where describes the offending token for the text format, e.g. " at 'x'"
+// or " at end".
+func (d *Diagnostic) where() string {
+	switch {
+	case d.AtEnd:
+		return " at end"
+	case d.Token != "":
+		return " at '" + d.Token + "'"
+	}
+	return ""
+}
+
+// Reportable is implemented by errors that can describe themselves as a
+// Diagnostic, such as scanner, parser and runtime errors.
+type Reportable interface {
+	Diagnostic() *Diagnostic
+}
+
+// From converts err into a Diagnostic. Errors that are not Reportable become
+// positionless diagnostics carrying err's message.
+func From(err error) *Diagnostic {
+	var d *Diagnostic
+	if errors.As(err, &d) {
+		return d
+	}
+	var r Reportable
+	if errors.As(err, &r) {
+		return r.Diagnostic()
+	}
+	return &Diagnostic{Severity: Error, Message: err.Error()}
+}
+
+// Reporter writes diagnostics to Out. In Text format each one is followed by
+// the source line it refers to and a caret underline when the position is
+// known.
+type Reporter struct {
+	Out    io.Writer
+	Format Format
+	lines  []string
+	counts map[Severity]int
+}
+
+func NewReporter(out io.Writer, source string) *Reporter {
+	return &Reporter{
+		Out:    out,
+		lines:  strings.Split(source, "\n"),
+		counts: map[Severity]int{},
+	}
+}
+
+func (r *Reporter) Report(d *Diagnostic) {
+	r.counts[d.Severity]++
+	if r.Format == JSON {
+		json.NewEncoder(r.Out).Encode(d)
+		return
+	}
+	fmt.Fprintln(r.Out, d.Error())
+	r.renderSnippet(d)
+	r.renderTrace(d)
+}
+
+func (r *Reporter) ReportError(err error) {
+	r.Report(From(err))
+}
+
+// Count returns how many diagnostics of the given severity were reported.
+func (r *Reporter) Count(severity Severity) int {
+	return r.counts[severity]
+}
+
+// maxRepeatedFrames is how many identical consecutive frames are printed
+// before the rest are summarized, so deep recursion stays readable.
+const maxRepeatedFrames = 3
+
+func (r *Reporter) renderTrace(d *Diagnostic) {
+	repeats := 0
+	for i, frame := range d.Trace {
+		if i > 0 && frame == d.Trace[i-1] {
+			repeats++
+		} else {
+			r.flushRepeats(repeats)
+			repeats = 0
+		}
+		if repeats < maxRepeatedFrames {
+			fmt.Fprintln(r.Out, frame)
+		}
+	}
+	r.flushRepeats(repeats)
+}
+
+func (r *Reporter) flushRepeats(repeats int) {
+	if repeats >= maxRepeatedFrames {
+		fmt.Fprintf(r.Out, "[Previous line repeated %d more times]\n", repeats-maxRepeatedFrames+1)
+	}
+}
+
+func (r *Reporter) renderSnippet(d *Diagnostic) {
+	if d.Line < 1 || d.Line > len(r.lines) {
+		return
+	}
+	line := strings.TrimRight(r.lines[d.Line-1], "\r")
+	gutter := fmt.Sprintf("%5d | ", d.Line)
+	fmt.Fprintf(r.Out, "%s%s\n", gutter, line)
+
+	// Columns count runes, not bytes.
+	runes := []rune(line)
+	if d.Column < 1 || d.Column > len(runes)+1 {
+		return
+	}
+	// Copy tabs from the source line so the caret stays aligned with it.
+	var pad strings.Builder
+	for _, c := range runes[:d.Column-1] {
+		if c == '\t' {
+			pad.WriteByte('\t')
+		} else {
+			pad.WriteByte(' ')
+		}
+	}
+	length := d.Length
+	if length < 1 {
+		length = 1
+	}
+	if rest := len(runes) - (d.Column - 1); length > rest && rest > 0 {
+		length = rest
+	}
+	fmt.Fprintf(r.Out, "%*s| %s%s\n", len(gutter)-2, "", pad.String(), strings.Repeat("^", length))
+}
@@ -0,0 +1,160 @@
+package diagnostics
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReportText(t *testing.T) {
+	f := Frame{Line: 2, Function: "f"}
+	tests := []struct {
+		name   string
+		source string
+		d      *Diagnostic
+		want   string
+	}{
+		{
+			"caret under token",
+			"print a * nil;",
+			&Diagnostic{Line: 1, Column: 9, Length: 1, Token: "*", Message: "Operands must be numbers."},
+			"[line 1] Error at '*': Operands must be numbers.\n" +
+				"    1 | print a * nil;\n" +
+				"      |         ^\n",
+		},
+		{
+			"underline spans the token",
+			"var x = 1;\nprint nope;",
+			&Diagnostic{Line: 2, Column: 7, Length: 4, Token: "nope", Message: "Undefined variable 'nope'."},
+			"[line 2] Error at 'nope': Undefined variable 'nope'.\n" +
+				"    2 | print nope;\n" +
+				"      |       ^^^^\n",
+		},
+		{
+			"columns count runes",
+			`print "héllo" - 1;`,
+			&Diagnostic{Line: 1, Column: 15, Length: 1, Token: "-", Message: "Operands must be numbers."},
+			"[line 1] Error at '-': Operands must be numbers.\n" +
+				`    1 | print "héllo" - 1;` + "\n" +
+				"      |               ^\n",
+		},
+		{
+			"tabs keep the caret aligned",
+			"\tprint -x;",
+			&Diagnostic{Line: 1, Column: 8, Length: 1, Token: "-", Message: "Operand must be a number."},
+			"[line 1] Error at '-': Operand must be a number.\n" +
+				"    1 | \tprint -x;\n" +
+				"      | \t      ^\n",
+		},
+		{
+			"at end",
+			"print (1",
+			&Diagnostic{Line: 1, Column: 9, AtEnd: true, Message: "Expect ')' after expression."},
+			"[line 1] Error at end: Expect ')' after expression.\n" +
+				"    1 | print (1\n" +
+				"      |         ^\n",
+		},
+		{
+			"underline clipped to the line",
+			"\"abc\r\ndef\"",
+			&Diagnostic{Line: 1, Column: 1, Length: 9, Token: "\"abc\r\ndef\"", Message: "Boom."},
+			"[line 1] Error at '\"abc\r\ndef\"': Boom.\n" +
+				"    1 | \"abc\n" +
+				"      | ^^^^\n",
+		},
+		{
+			"no position",
+			"print 1;",
+			&Diagnostic{Message: "Something went wrong."},
+			"Error: Something went wrong.\n",
+		},
+		{
+			"traceback",
+			"fun f() {}",
+			&Diagnostic{Line: 1, Column: 1, Length: 1, Message: "Boom.", Trace: []Frame{{Line: 1, Function: "g"}, {Line: 3}}},
+			"[line 1] Error: Boom.\n" +
+				"    1 | fun f() {}\n" +
+				"      | ^\n" +
+				"[line 1] in g()\n" +
+				"[line 3] in script\n",
+		},
+		{
+			"repeated frames collapse",
+			"",
+			&Diagnostic{Message: "Stack overflow.", Trace: []Frame{f, f, f, f, f, {Line: 4}}},
+			"Error: Stack overflow.\n" +
+				"[line 2] in f()\n" +
+				"[line 2] in f()\n" +
+				"[line 2] in f()\n" +
+				"[Previous line repeated 2 more times]\n" +
+				"[line 4] in script\n",
+		},
+		{
+			"three repeats are all shown",
+			"",
+			&Diagnostic{Message: "Stack overflow.", Trace: []Frame{f, f, f}},
+			"Error: Stack overflow.\n" +
+				"[line 2] in f()\n" +
+				"[line 2] in f()\n" +
+				"[line 2] in f()\n",
+		},
+		{
+			"collapse at the end of the trace",
+			"",
+			&Diagnostic{Message: "Stack overflow.", Trace: []Frame{f, f, f, f}},
+			"Error: Stack overflow.\n" +
+				"[line 2] in f()\n" +
+				"[line 2] in f()\n" +
+				"[line 2] in f()\n" +
+				"[Previous line repeated 1 more times]\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			NewReporter(&out, test.source).Report(test.d)
+			if got := out.String(); got != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReportJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		d    *Diagnostic
+		want string
+	}{
+		{
+			"token",
+			&Diagnostic{Line: 1, Column: 9, Length: 1, Token: "*", Message: "Operands must be numbers."},
+			`{"severity":"error","line":1,"column":9,"length":1,"token":"*","message":"Operands must be numbers."}`,
+		},
+		{
+			"at end",
+			&Diagnostic{Line: 2, Column: 1, AtEnd: true, Message: "Expect ')' after expression."},
+			`{"severity":"error","line":2,"column":1,"length":0,"atEnd":true,"message":"Expect ')' after expression."}`,
+		},
+		{
+			"no token",
+			&Diagnostic{Line: 1, Column: 1, Length: 1, Message: "Unexpected character: @"},
+			`{"severity":"error","line":1,"column":1,"length":1,"message":"Unexpected character: @"}`,
+		},
+		{
+			"trace",
+			&Diagnostic{Line: 2, Column: 3, Length: 1, Token: "+", Message: "Boom.", Trace: []Frame{{Line: 2, Function: "f"}, {Line: 3}}},
+			`{"severity":"error","line":2,"column":3,"length":1,"token":"+","message":"Boom.","trace":[{"line":2,"function":"f"},{"line":3,"function":""}]}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			reporter := NewReporter(&out, "")
+			reporter.Format = JSON
+			reporter.Report(test.d)
+			if got := out.String(); got != test.want+"\n" {
+				t.Errorf("got  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
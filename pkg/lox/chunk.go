@@ -0,0 +1,78 @@
+package lox
+
+// OpCode is a single bytecode instruction. Operands follow the opcode in
+// the chunk: constant indexes and jump offsets take two bytes (big-endian),
+// local slots, upvalue indexes and argument counts take one.
+type OpCode byte
+
+const (
+	OpConstant OpCode = iota
+	OpNil
+	OpTrue
+	OpFalse
+	OpPop
+	OpGetLocal
+	OpSetLocal
+	OpGetGlobal
+	OpDefineGlobal
+	OpSetGlobal
+	OpGetUpvalue
+	OpSetUpvalue
+	OpGetProperty
+	OpSetProperty
+	OpGetSuper
+	OpEqual
+	OpGreater
+	OpGreaterEqual
+	OpLess
+	OpLessEqual
+	OpAdd
+	OpSubtract
+	OpMultiply
+	OpDivide
+	OpNot
+	OpNegate
+	OpPrint
+	OpJump
+	OpJumpIfFalse
+	OpLoop
+	OpCall
+	OpClosure
+	OpCloseUpvalue
+	OpReturn
+	OpClass
+	OpInherit
+	OpMethod
+)
+
+// Chunk is the compiled body of one function.
+type Chunk struct {
+	Code      []byte
+	Constants []interface{}
+	// Lines holds the source line of every byte in Code.
+	Lines []int
+	// tokens maps the offset of each instruction that can fail at runtime to
+	// the token the tree walker would blame, so both engines report errors
+	// identically.
+	tokens map[int]Token
+}
+
+func (c *Chunk) Write(b byte, line int) {
+	c.Code = append(c.Code, b)
+	c.Lines = append(c.Lines, line)
+}
+
+// AddConstant appends value to the constant pool and returns its index.
+func (c *Chunk) AddConstant(value interface{}) int {
+	c.Constants = append(c.Constants, value)
+	return len(c.Constants) - 1
+}
+
+// tokenAt returns the token blamed for a runtime error raised by the
+// instruction at offset.
+func (c *Chunk) tokenAt(offset int) Token {
+	if token, ok := c.tokens[offset]; ok {
+		return token
+	}
+	return Token{Line: c.Lines[offset]}
+}
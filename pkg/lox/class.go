@@ -0,0 +1,66 @@
+package lox
+
+import "fmt"
+
+type LoxClass struct {
+	Name       string
+	Superclass *LoxClass
+	Methods    map[*Symbol]*LoxFunction
+}
+
+// FindMethod walks up the superclass chain until it finds name.
+func (c *LoxClass) FindMethod(name *Symbol) *LoxFunction {
+	if method, ok := c.Methods[name]; ok {
+		return method
+	}
+	if c.Superclass != nil {
+		return c.Superclass.FindMethod(name)
+	}
+	return nil
+}
+
+// Arity is the arity of the class's initializer, or zero if it has none.
+func (c *LoxClass) Arity() int {
+	if initializer := c.FindMethod(initSymbol); initializer != nil {
+		return initializer.Arity()
+	}
+	return 0
+}
+
+func (c *LoxClass) Call(e *Evaluator, arguments []interface{}) (interface{}, error) {
+	instance := &LoxInstance{Class: c, Fields: map[*Symbol]interface{}{}}
+	if initializer := c.FindMethod(initSymbol); initializer != nil {
+		if _, err := initializer.Bind(instance).Call(e, arguments); err != nil {
+			return nil, err
+		}
+	}
+	return instance, nil
+}
+
+func (c *LoxClass) String() string {
+	return c.Name
+}
+
+type LoxInstance struct {
+	Class  *LoxClass
+	Fields map[*Symbol]interface{}
+}
+
+// Get looks up a field first, so fields shadow methods of the same name.
+func (i *LoxInstance) Get(name Token) (interface{}, error) {
+	if value, ok := i.Fields[name.Symbol]; ok {
+		return value, nil
+	}
+	if method := i.Class.FindMethod(name.Symbol); method != nil {
+		return method.Bind(i), nil
+	}
+	return nil, &RuntimeError{Message: fmt.Sprintf("Undefined property '%s'.", name.Lexeme), Token: name}
+}
+
+func (i *LoxInstance) Set(name Token, value interface{}) {
+	i.Fields[name.Symbol] = value
+}
+
+func (i *LoxInstance) String() string {
+	return fmt.Sprintf("%s instance", i.Class.Name)
+}
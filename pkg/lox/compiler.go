@@ -0,0 +1,702 @@
+package lox
+
+const (
+	maxLocals   = 256
+	maxUpvalues = 256
+	maxConstant = 0xFFFF
+	maxJump     = 0xFFFF
+)
+
+type local struct {
+	name       string
+	depth      int
+	isCaptured bool
+}
+
+type upvalueRef struct {
+	index   byte
+	isLocal bool
+}
+
+// funcCompiler holds the state for the function currently being compiled.
+// Compiling a nested function pushes a new one linked through enclosing.
+type funcCompiler struct {
+	enclosing  *funcCompiler
+	function   *ObjFunction
+	kind       functionType
+	locals     []local
+	upvalues   []upvalueRef
+	scopeDepth int
+}
+
+type classCompiler struct {
+	enclosing     *classCompiler
+	hasSuperclass bool
+}
+
+// Compiler translates an AST into bytecode for the VM. It expects the AST to
+// have passed the Resolver already, so scope errors are not checked again;
+// the only errors it reports are bytecode limits being exceeded.
+type Compiler struct {
+	vm           *VM
+	current      *funcCompiler
+	currentClass *classCompiler
+	// token is the most recent token seen, used to attribute emitted bytes
+	// to a source line and to report limit errors.
+	token Token
+}
+
+func NewCompiler(vm *VM) *Compiler {
+	return &Compiler{vm: vm}
+}
+
+// Compile compiles a program into the function that runs its top level.
+func (c *Compiler) Compile(statements []Stmt) (*ObjFunction, error) {
+	c.vm.compiler = c
+	defer func() { c.vm.compiler = nil }()
+	c.beginFunction(functionNone, "")
+	for _, stmt := range statements {
+		if err := c.statement(stmt); err != nil {
+			return nil, err
+		}
+	}
+	function, _ := c.endFunction()
+	return function, nil
+}
+
+// CompileExpression compiles a single expression into a function that
+// returns its value.
+func (c *Compiler) CompileExpression(expr Expr) (*ObjFunction, error) {
+	c.vm.compiler = c
+	defer func() { c.vm.compiler = nil }()
+	c.beginFunction(functionNone, "")
+	if err := c.expression(expr); err != nil {
+		return nil, err
+	}
+	c.emitOp(OpReturn)
+	function := c.current.function
+	c.current = c.current.enclosing
+	return function, nil
+}
+
+func (c *Compiler) statement(stmt Stmt) error {
+	switch stmt := stmt.(type) {
+	case PrintStmt:
+		if err := c.expression(stmt.Expression); err != nil {
+			return err
+		}
+		c.emitOp(OpPrint)
+		return nil
+	case ExpressionStmt:
+		if err := c.expression(stmt.Expression); err != nil {
+			return err
+		}
+		c.emitOp(OpPop)
+		return nil
+	case VarStmt:
+		return c.varDeclaration(&stmt)
+	case BlockStmt:
+		c.beginScope()
+		for _, inner := range stmt.Statements {
+			if err := c.statement(inner); err != nil {
+				return err
+			}
+		}
+		c.endScope()
+		return nil
+	case IfStmt:
+		return c.ifStatement(&stmt)
+	case WhileStmt:
+		return c.whileStatement(&stmt)
+	case FunctionStmt:
+		return c.funDeclaration(&stmt)
+	case ReturnStmt:
+		c.token = stmt.Keyword
+		if stmt.Value == nil {
+			c.emitReturn()
+			return nil
+		}
+		if err := c.expression(stmt.Value); err != nil {
+			return err
+		}
+		c.emitOp(OpReturn)
+		return nil
+	case ClassStmt:
+		return c.classDeclaration(&stmt)
+	default:
+		return &ParserError{Message: "Unknown statement type", Token: c.token}
+	}
+}
+
+func (c *Compiler) varDeclaration(stmt *VarStmt) error {
+	c.token = stmt.Name
+	if err := c.declareVariable(stmt.Name); err != nil {
+		return err
+	}
+	if stmt.Initializer != nil {
+		if err := c.expression(stmt.Initializer); err != nil {
+			return err
+		}
+	} else {
+		c.emitOp(OpNil)
+	}
+	return c.defineVariable(stmt.Name)
+}
+
+func (c *Compiler) ifStatement(stmt *IfStmt) error {
+	if err := c.expression(stmt.Condition); err != nil {
+		return err
+	}
+	thenJump := c.emitJump(OpJumpIfFalse)
+	c.emitOp(OpPop)
+	if err := c.statement(stmt.ThenBranch); err != nil {
+		return err
+	}
+	elseJump := c.emitJump(OpJump)
+
+	if err := c.patchJump(thenJump); err != nil {
+		return err
+	}
+	c.emitOp(OpPop)
+	if stmt.ElseBranch != nil {
+		if err := c.statement(stmt.ElseBranch); err != nil {
+			return err
+		}
+	}
+	return c.patchJump(elseJump)
+}
+
+func (c *Compiler) whileStatement(stmt *WhileStmt) error {
+	loopStart := len(c.chunk().Code)
+	if err := c.expression(stmt.Condition); err != nil {
+		return err
+	}
+	exitJump := c.emitJump(OpJumpIfFalse)
+	c.emitOp(OpPop)
+	if err := c.statement(stmt.Body); err != nil {
+		return err
+	}
+	if err := c.emitLoop(loopStart); err != nil {
+		return err
+	}
+
+	if err := c.patchJump(exitJump); err != nil {
+		return err
+	}
+	c.emitOp(OpPop)
+	return nil
+}
+
+func (c *Compiler) funDeclaration(stmt *FunctionStmt) error {
+	c.token = stmt.Name
+	if err := c.declareVariable(stmt.Name); err != nil {
+		return err
+	}
+	// A function may refer to itself, so it is usable before its body is
+	// compiled.
+	c.markInitialized()
+	if err := c.function(stmt, functionFunction); err != nil {
+		return err
+	}
+	return c.defineVariable(stmt.Name)
+}
+
+// function compiles a function body in a fresh funcCompiler and emits the
+// closure that creates it at runtime.
+func (c *Compiler) function(stmt *FunctionStmt, kind functionType) error {
+	c.beginFunction(kind, stmt.Name.Lexeme)
+	c.beginScope()
+	for _, param := range stmt.Params {
+		c.current.function.Arity++
+		if err := c.declareVariable(param); err != nil {
+			return err
+		}
+		if err := c.defineVariable(param); err != nil {
+			return err
+		}
+	}
+	for _, inner := range stmt.Body {
+		if err := c.statement(inner); err != nil {
+			return err
+		}
+	}
+	function, upvalues := c.endFunction()
+
+	c.token = stmt.Name
+	index, err := c.makeConstant(function)
+	if err != nil {
+		return err
+	}
+	c.emitOp(OpClosure)
+	c.emitShort(index)
+	for _, upvalue := range upvalues {
+		if upvalue.isLocal {
+			c.emitByte(1)
+		} else {
+			c.emitByte(0)
+		}
+		c.emitByte(upvalue.index)
+	}
+	return nil
+}
+
+func (c *Compiler) classDeclaration(stmt *ClassStmt) error {
+	c.token = stmt.Name
+	nameConstant, err := c.identifierConstant(stmt.Name)
+	if err != nil {
+		return err
+	}
+	if err := c.declareVariable(stmt.Name); err != nil {
+		return err
+	}
+	c.emitOp(OpClass)
+	c.emitShort(nameConstant)
+	if err := c.defineVariable(stmt.Name); err != nil {
+		return err
+	}
+
+	class := &classCompiler{enclosing: c.currentClass}
+	c.currentClass = class
+	defer func() { c.currentClass = class.enclosing }()
+
+	if stmt.Superclass != nil {
+		if err := c.namedVariable(stmt.Superclass.Name, false); err != nil {
+			return err
+		}
+		// "super" lives in its own scope around the methods so each method
+		// captures the superclass as an upvalue.
+		c.beginScope()
+		if err := c.addLocal(Token{Type: "SUPER", Lexeme: "super", Line: stmt.Name.Line}); err != nil {
+			return err
+		}
+		if err := c.defineVariable(stmt.Name); err != nil {
+			return err
+		}
+		if err := c.namedVariable(stmt.Name, false); err != nil {
+			return err
+		}
+		c.emitOpAt(OpInherit, stmt.Superclass.Name)
+		class.hasSuperclass = true
+	}
+
+	if err := c.namedVariable(stmt.Name, false); err != nil {
+		return err
+	}
+	for i := range stmt.Methods {
+		method := &stmt.Methods[i]
+		c.token = method.Name
+		constant, err := c.identifierConstant(method.Name)
+		if err != nil {
+			return err
+		}
+		kind := functionMethod
+		if method.Name.Lexeme == "init" {
+			kind = functionInitializer
+		}
+		if err := c.function(method, kind); err != nil {
+			return err
+		}
+		c.emitOp(OpMethod)
+		c.emitShort(constant)
+	}
+	c.emitOp(OpPop)
+
+	if class.hasSuperclass {
+		c.endScope()
+	}
+	return nil
+}
+
+func (c *Compiler) expression(expr Expr) error {
+	switch expr := expr.(type) {
+	case Literal:
+		c.token = expr.token
+		switch v := expr.Value.(type) {
+		case nil:
+			c.emitOp(OpNil)
+		case bool:
+			if v {
+				c.emitOp(OpTrue)
+			} else {
+				c.emitOp(OpFalse)
+			}
+		case string:
+			return c.emitConstant(c.vm.newString(v))
+		default:
+			return c.emitConstant(v)
+		}
+		return nil
+	case Grouping:
+		return c.expression(expr.Expression)
+	case UnaryExpr:
+		if err := c.expression(expr.Right); err != nil {
+			return err
+		}
+		if expr.Operator.Type == "MINUS" {
+			c.emitOpAt(OpNegate, expr.Operator)
+		} else {
+			c.emitOpAt(OpNot, expr.Operator)
+		}
+		return nil
+	case BinaryExpr:
+		return c.binary(&expr)
+	case LogicalExpr:
+		return c.logical(&expr)
+	case *VariableExpr:
+		return c.namedVariable(expr.Name, false)
+	case *AssignExpr:
+		if err := c.expression(expr.Value); err != nil {
+			return err
+		}
+		return c.namedVariable(expr.Name, true)
+	case CallExpr:
+		if err := c.expression(expr.Callee); err != nil {
+			return err
+		}
+		for _, arg := range expr.Arguments {
+			if err := c.expression(arg); err != nil {
+				return err
+			}
+		}
+		c.emitOpAt(OpCall, expr.Paren)
+		c.emitByte(byte(len(expr.Arguments)))
+		return nil
+	case GetExpr:
+		if err := c.expression(expr.Object); err != nil {
+			return err
+		}
+		return c.emitNamedOp(OpGetProperty, expr.Name)
+	case SetExpr:
+		if err := c.expression(expr.Object); err != nil {
+			return err
+		}
+		if err := c.expression(expr.Value); err != nil {
+			return err
+		}
+		return c.emitNamedOp(OpSetProperty, expr.Name)
+	case *ThisExpr:
+		return c.namedVariable(expr.Keyword, false)
+	case *SuperExpr:
+		this := Token{Type: "THIS", Lexeme: "this", Line: expr.Keyword.Line, Column: expr.Keyword.Column}
+		if err := c.namedVariable(this, false); err != nil {
+			return err
+		}
+		if err := c.namedVariable(expr.Keyword, false); err != nil {
+			return err
+		}
+		return c.emitNamedOp(OpGetSuper, expr.Method)
+	default:
+		return &ParserError{Message: "Unknown expression type", Token: c.token}
+	}
+}
+
+var binaryOps = map[string]OpCode{
+	"PLUS":          OpAdd,
+	"MINUS":         OpSubtract,
+	"STAR":          OpMultiply,
+	"SLASH":         OpDivide,
+	"GREATER":       OpGreater,
+	"GREATER_EQUAL": OpGreaterEqual,
+	"LESS":          OpLess,
+	"LESS_EQUAL":    OpLessEqual,
+	"EQUAL_EQUAL":   OpEqual,
+	"BANG_EQUAL":    OpEqual,
+}
+
+func (c *Compiler) binary(expr *BinaryExpr) error {
+	if err := c.expression(expr.Left); err != nil {
+		return err
+	}
+	if err := c.expression(expr.Right); err != nil {
+		return err
+	}
+	op, ok := binaryOps[expr.Operator.Type]
+	if !ok {
+		return &ParserError{Message: "Unknown binary operator", Token: expr.Operator}
+	}
+	c.emitOpAt(op, expr.Operator)
+	if expr.Operator.Type == "BANG_EQUAL" {
+		c.emitOp(OpNot)
+	}
+	return nil
+}
+
+func (c *Compiler) logical(expr *LogicalExpr) error {
+	if err := c.expression(expr.Left); err != nil {
+		return err
+	}
+	c.token = expr.Operator
+
+	if expr.Operator.Type == "OR" {
+		elseJump := c.emitJump(OpJumpIfFalse)
+		endJump := c.emitJump(OpJump)
+		if err := c.patchJump(elseJump); err != nil {
+			return err
+		}
+		c.emitOp(OpPop)
+		if err := c.expression(expr.Right); err != nil {
+			return err
+		}
+		return c.patchJump(endJump)
+	}
+
+	endJump := c.emitJump(OpJumpIfFalse)
+	c.emitOp(OpPop)
+	if err := c.expression(expr.Right); err != nil {
+		return err
+	}
+	return c.patchJump(endJump)
+}
+
+// namedVariable emits a read, or a write of the value on top of the stack,
+// for name as a local, an upvalue or a global, in that order.
+func (c *Compiler) namedVariable(name Token, assign bool) error {
+	c.token = name
+	if slot := c.current.resolveLocal(name.Lexeme); slot != -1 {
+		if assign {
+			c.emitOp(OpSetLocal)
+		} else {
+			c.emitOp(OpGetLocal)
+		}
+		c.emitByte(byte(slot))
+		return nil
+	}
+
+	index, err := c.resolveUpvalue(c.current, name)
+	if err != nil {
+		return err
+	}
+	if index != -1 {
+		if assign {
+			c.emitOp(OpSetUpvalue)
+		} else {
+			c.emitOp(OpGetUpvalue)
+		}
+		c.emitByte(byte(index))
+		return nil
+	}
+
+	if assign {
+		return c.emitNamedOp(OpSetGlobal, name)
+	}
+	return c.emitNamedOp(OpGetGlobal, name)
+}
+
+func (fc *funcCompiler) resolveLocal(name string) int {
+	for i := len(fc.locals) - 1; i >= 0; i-- {
+		if fc.locals[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *Compiler) resolveUpvalue(fc *funcCompiler, name Token) (int, error) {
+	if fc.enclosing == nil {
+		return -1, nil
+	}
+	if slot := fc.enclosing.resolveLocal(name.Lexeme); slot != -1 {
+		fc.enclosing.locals[slot].isCaptured = true
+		return c.addUpvalue(fc, byte(slot), true, name)
+	}
+	index, err := c.resolveUpvalue(fc.enclosing, name)
+	if err != nil || index == -1 {
+		return index, err
+	}
+	return c.addUpvalue(fc, byte(index), false, name)
+}
+
+func (c *Compiler) addUpvalue(fc *funcCompiler, index byte, isLocal bool, name Token) (int, error) {
+	for i, upvalue := range fc.upvalues {
+		if upvalue.index == index && upvalue.isLocal == isLocal {
+			return i, nil
+		}
+	}
+	if len(fc.upvalues) == maxUpvalues {
+		return -1, &ParserError{Message: "Too many closure variables in function.", Token: name}
+	}
+	fc.upvalues = append(fc.upvalues, upvalueRef{index: index, isLocal: isLocal})
+	return len(fc.upvalues) - 1, nil
+}
+
+func (c *Compiler) declareVariable(name Token) error {
+	if c.current.scopeDepth == 0 {
+		return nil
+	}
+	return c.addLocal(name)
+}
+
+func (c *Compiler) addLocal(name Token) error {
+	if len(c.current.locals) == maxLocals {
+		return &ParserError{Message: "Too many local variables in function.", Token: name}
+	}
+	c.current.locals = append(c.current.locals, local{name: name.Lexeme, depth: -1})
+	return nil
+}
+
+// defineVariable makes a just-declared variable available: locals already
+// sit in their stack slot, globals are stored by name.
+func (c *Compiler) defineVariable(name Token) error {
+	if c.current.scopeDepth > 0 {
+		c.markInitialized()
+		return nil
+	}
+	index, err := c.identifierConstant(name)
+	if err != nil {
+		return err
+	}
+	c.emitOp(OpDefineGlobal)
+	c.emitShort(index)
+	return nil
+}
+
+func (c *Compiler) markInitialized() {
+	if c.current.scopeDepth == 0 {
+		return
+	}
+	c.current.locals[len(c.current.locals)-1].depth = c.current.scopeDepth
+}
+
+func (c *Compiler) beginScope() {
+	c.current.scopeDepth++
+}
+
+// endScope discards the scope's locals, closing over any that were captured.
+func (c *Compiler) endScope() {
+	fc := c.current
+	fc.scopeDepth--
+	for len(fc.locals) > 0 && fc.locals[len(fc.locals)-1].depth > fc.scopeDepth {
+		if fc.locals[len(fc.locals)-1].isCaptured {
+			c.emitOp(OpCloseUpvalue)
+		} else {
+			c.emitOp(OpPop)
+		}
+		fc.locals = fc.locals[:len(fc.locals)-1]
+	}
+}
+
+func (c *Compiler) beginFunction(kind functionType, name string) {
+	fc := &funcCompiler{
+		enclosing: c.current,
+		function:  c.vm.newFunction(name),
+		kind:      kind,
+	}
+	// Slot zero holds the callee, or the receiver inside methods.
+	slot := ""
+	if kind == functionMethod || kind == functionInitializer {
+		slot = "this"
+	}
+	fc.locals = append(fc.locals, local{name: slot, depth: 0})
+	c.current = fc
+}
+
+func (c *Compiler) endFunction() (*ObjFunction, []upvalueRef) {
+	c.emitReturn()
+	fc := c.current
+	fc.function.UpvalueCount = len(fc.upvalues)
+	c.current = fc.enclosing
+	return fc.function, fc.upvalues
+}
+
+func (c *Compiler) chunk() *Chunk {
+	return &c.current.function.Chunk
+}
+
+func (c *Compiler) emitByte(b byte) {
+	c.chunk().Write(b, c.token.Line)
+}
+
+func (c *Compiler) emitOp(op OpCode) {
+	c.emitByte(byte(op))
+}
+
+// emitOpAt emits op and records token as the one to blame if op fails at
+// runtime.
+func (c *Compiler) emitOpAt(op OpCode, token Token) {
+	c.token = token
+	chunk := c.chunk()
+	if chunk.tokens == nil {
+		chunk.tokens = map[int]Token{}
+	}
+	chunk.tokens[len(chunk.Code)] = token
+	c.emitOp(op)
+}
+
+// emitNamedOp emits an instruction whose operand is the constant holding
+// name's identifier.
+func (c *Compiler) emitNamedOp(op OpCode, name Token) error {
+	index, err := c.identifierConstant(name)
+	if err != nil {
+		return err
+	}
+	c.emitOpAt(op, name)
+	c.emitShort(index)
+	return nil
+}
+
+func (c *Compiler) emitShort(value int) {
+	c.emitByte(byte(value >> 8))
+	c.emitByte(byte(value))
+}
+
+func (c *Compiler) emitReturn() {
+	if c.current.kind == functionInitializer {
+		c.emitOp(OpGetLocal)
+		c.emitByte(0)
+	} else {
+		c.emitOp(OpNil)
+	}
+	c.emitOp(OpReturn)
+}
+
+func (c *Compiler) emitConstant(value interface{}) error {
+	index, err := c.makeConstant(value)
+	if err != nil {
+		return err
+	}
+	c.emitOp(OpConstant)
+	c.emitShort(index)
+	return nil
+}
+
+func (c *Compiler) makeConstant(value interface{}) (int, error) {
+	index := c.chunk().AddConstant(value)
+	if index > maxConstant {
+		return 0, &ParserError{Message: "Too many constants in one chunk.", Token: c.token}
+	}
+	return index, nil
+}
+
+func (c *Compiler) identifierConstant(name Token) (int, error) {
+	return c.makeConstant(c.vm.newString(name.Lexeme))
+}
+
+// emitJump emits a jump with a placeholder offset and returns the position
+// of that offset for patchJump.
+func (c *Compiler) emitJump(op OpCode) int {
+	c.emitOp(op)
+	c.emitByte(0xff)
+	c.emitByte(0xff)
+	return len(c.chunk().Code) - 2
+}
+
+func (c *Compiler) patchJump(offset int) error {
+	code := c.chunk().Code
+	jump := len(code) - offset - 2
+	if jump > maxJump {
+		return &ParserError{Message: "Too much code to jump over.", Token: c.token}
+	}
+	code[offset] = byte(jump >> 8)
+	code[offset+1] = byte(jump)
+	return nil
+}
+
+func (c *Compiler) emitLoop(loopStart int) error {
+	c.emitOp(OpLoop)
+	offset := len(c.chunk().Code) - loopStart + 2
+	if offset > maxJump {
+		return &ParserError{Message: "Loop body too large.", Token: c.token}
+	}
+	c.emitShort(offset)
+	return nil
+}
@@ -0,0 +1,149 @@
+package lox
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+var opNames = [...]string{
+	OpConstant:     "OP_CONSTANT",
+	OpNil:          "OP_NIL",
+	OpTrue:         "OP_TRUE",
+	OpFalse:        "OP_FALSE",
+	OpPop:          "OP_POP",
+	OpGetLocal:     "OP_GET_LOCAL",
+	OpSetLocal:     "OP_SET_LOCAL",
+	OpGetGlobal:    "OP_GET_GLOBAL",
+	OpDefineGlobal: "OP_DEFINE_GLOBAL",
+	OpSetGlobal:    "OP_SET_GLOBAL",
+	OpGetUpvalue:   "OP_GET_UPVALUE",
+	OpSetUpvalue:   "OP_SET_UPVALUE",
+	OpGetProperty:  "OP_GET_PROPERTY",
+	OpSetProperty:  "OP_SET_PROPERTY",
+	OpGetSuper:     "OP_GET_SUPER",
+	OpEqual:        "OP_EQUAL",
+	OpGreater:      "OP_GREATER",
+	OpGreaterEqual: "OP_GREATER_EQUAL",
+	OpLess:         "OP_LESS",
+	OpLessEqual:    "OP_LESS_EQUAL",
+	OpAdd:          "OP_ADD",
+	OpSubtract:     "OP_SUBTRACT",
+	OpMultiply:     "OP_MULTIPLY",
+	OpDivide:       "OP_DIVIDE",
+	OpNot:          "OP_NOT",
+	OpNegate:       "OP_NEGATE",
+	OpPrint:        "OP_PRINT",
+	OpJump:         "OP_JUMP",
+	OpJumpIfFalse:  "OP_JUMP_IF_FALSE",
+	OpLoop:         "OP_LOOP",
+	OpCall:         "OP_CALL",
+	OpClosure:      "OP_CLOSURE",
+	OpCloseUpvalue: "OP_CLOSE_UPVALUE",
+	OpReturn:       "OP_RETURN",
+	OpClass:        "OP_CLASS",
+	OpInherit:      "OP_INHERIT",
+	OpMethod:       "OP_METHOD",
+}
+
+func (op OpCode) String() string {
+	if int(op) < len(opNames) {
+		return opNames[op]
+	}
+	return fmt.Sprintf("OP_UNKNOWN(%d)", byte(op))
+}
+
+// Disassemble prints the bytecode of function followed by that of every
+// function nested in it.
+func Disassemble(w io.Writer, function *ObjFunction) {
+	DisassembleChunk(w, &function.Chunk, function.String())
+	for _, constant := range function.Chunk.Constants {
+		if nested, ok := constant.(*ObjFunction); ok {
+			fmt.Fprintln(w)
+			Disassemble(w, nested)
+		}
+	}
+}
+
+// DisassembleChunk prints every instruction in chunk under a header naming
+// it.
+func DisassembleChunk(w io.Writer, chunk *Chunk, name string) {
+	fmt.Fprintf(w, "== %s ==\n", name)
+	for offset := 0; offset < len(chunk.Code); {
+		offset = DisassembleInstruction(w, chunk, offset)
+	}
+}
+
+// DisassembleInstruction prints the instruction at offset and returns the
+// offset of the next one. The source line is shown as "|" when it is the
+// same as the previous instruction's.
+func DisassembleInstruction(w io.Writer, chunk *Chunk, offset int) int {
+	fmt.Fprintf(w, "%04d ", offset)
+	if offset > 0 && chunk.Lines[offset] == chunk.Lines[offset-1] {
+		fmt.Fprint(w, "   | ")
+	} else {
+		fmt.Fprintf(w, "%4d ", chunk.Lines[offset])
+	}
+
+	op := OpCode(chunk.Code[offset])
+	switch op {
+	case OpConstant, OpGetGlobal, OpDefineGlobal, OpSetGlobal,
+		OpGetProperty, OpSetProperty, OpGetSuper, OpClass, OpMethod:
+		return constantInstruction(w, op, chunk, offset)
+	case OpGetLocal, OpSetLocal, OpGetUpvalue, OpSetUpvalue, OpCall:
+		fmt.Fprintf(w, "%-16s %4d\n", op, chunk.Code[offset+1])
+		return offset + 2
+	case OpJump, OpJumpIfFalse:
+		return jumpInstruction(w, op, 1, chunk, offset)
+	case OpLoop:
+		return jumpInstruction(w, op, -1, chunk, offset)
+	case OpClosure:
+		return closureInstruction(w, chunk, offset)
+	default:
+		fmt.Fprintln(w, op)
+		return offset + 1
+	}
+}
+
+func readShortAt(chunk *Chunk, offset int) int {
+	return int(chunk.Code[offset])<<8 | int(chunk.Code[offset+1])
+}
+
+func constantInstruction(w io.Writer, op OpCode, chunk *Chunk, offset int) int {
+	index := readShortAt(chunk, offset+1)
+	fmt.Fprintf(w, "%-16s %4d '%s'\n", op, index, Stringify(chunk.Constants[index]))
+	return offset + 3
+}
+
+func jumpInstruction(w io.Writer, op OpCode, sign int, chunk *Chunk, offset int) int {
+	jump := readShortAt(chunk, offset+1)
+	fmt.Fprintf(w, "%-16s %4d -> %d\n", op, offset, offset+3+sign*jump)
+	return offset + 3
+}
+
+func closureInstruction(w io.Writer, chunk *Chunk, offset int) int {
+	index := readShortAt(chunk, offset+1)
+	function := chunk.Constants[index].(*ObjFunction)
+	fmt.Fprintf(w, "%-16s %4d %s\n", OpClosure, index, function)
+	offset += 3
+	for i := 0; i < function.UpvalueCount; i++ {
+		kind := "upvalue"
+		if chunk.Code[offset] == 1 {
+			kind = "local"
+		}
+		fmt.Fprintf(w, "%04d    |                     %s %d\n", offset, kind, chunk.Code[offset+1])
+		offset += 2
+	}
+	return offset
+}
+
+// traceStack prints the VM's stack from the bottom up, one slot per
+// bracket.
+func (vm *VM) traceStack(w io.Writer) {
+	var b strings.Builder
+	b.WriteString("          ")
+	for _, value := range vm.stack[:vm.sp] {
+		fmt.Fprintf(&b, "[ %s ]", Stringify(value))
+	}
+	fmt.Fprintln(w, b.String())
+}
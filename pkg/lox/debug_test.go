@@ -0,0 +1,145 @@
+package lox
+
+import (
+	"bytes"
+	"testing"
+)
+
+var disassembleTests = []struct {
+	name   string
+	source string
+	want   string
+}{
+	{
+		"function call",
+		`fun add(a, b) {
+  return a + b;
+}
+var s = "hi";
+print add(1, 2) + 3;
+`,
+		`== <script> ==
+0000    1 OP_CLOSURE          0 <fn add>
+0003    | OP_DEFINE_GLOBAL    1 'add'
+0006    4 OP_CONSTANT         2 'hi'
+0009    | OP_DEFINE_GLOBAL    3 's'
+0012    5 OP_GET_GLOBAL       4 'add'
+0015    | OP_CONSTANT         5 '1'
+0018    | OP_CONSTANT         6 '2'
+0021    | OP_CALL             2
+0023    | OP_CONSTANT         7 '3'
+0026    | OP_ADD
+0027    | OP_PRINT
+0028    | OP_NIL
+0029    | OP_RETURN
+
+== <fn add> ==
+0000    2 OP_GET_LOCAL        1
+0002    | OP_GET_LOCAL        2
+0004    | OP_ADD
+0005    | OP_RETURN
+0006    | OP_NIL
+0007    | OP_RETURN
+`,
+	},
+	{
+		"closures, jumps and classes",
+		`fun outer() {
+  var x = 1;
+  fun inner() { return x; }
+  return inner;
+}
+for (var i = 0; i < 2; i = i + 1) {
+  if (i and true) print i; else print nil;
+}
+class A < B {
+  f() { return super.f(this.x); }
+}
+`,
+		`== <script> ==
+0000    1 OP_CLOSURE          0 <fn outer>
+0003    | OP_DEFINE_GLOBAL    1 'outer'
+0006    6 OP_CONSTANT         2 '0'
+0009    | OP_GET_LOCAL        1
+0011    | OP_CONSTANT         3 '2'
+0014    | OP_LESS
+0015    | OP_JUMP_IF_FALSE   15 -> 51
+0018    | OP_POP
+0019    7 OP_GET_LOCAL        1
+0021    | OP_JUMP_IF_FALSE   21 -> 26
+0024    | OP_POP
+0025    | OP_TRUE
+0026    | OP_JUMP_IF_FALSE   26 -> 36
+0029    | OP_POP
+0030    | OP_GET_LOCAL        1
+0032    | OP_PRINT
+0033    | OP_JUMP            33 -> 39
+0036    | OP_POP
+0037    | OP_NIL
+0038    | OP_PRINT
+0039    6 OP_GET_LOCAL        1
+0041    | OP_CONSTANT         4 '1'
+0044    | OP_ADD
+0045    | OP_SET_LOCAL        1
+0047    | OP_POP
+0048    | OP_LOOP            48 -> 9
+0051    | OP_POP
+0052    | OP_POP
+0053    9 OP_CLASS            5 'A'
+0056    | OP_DEFINE_GLOBAL    6 'A'
+0059    | OP_GET_GLOBAL       7 'B'
+0062    | OP_GET_GLOBAL       8 'A'
+0065    | OP_INHERIT
+0066    | OP_GET_GLOBAL       9 'A'
+0069   10 OP_CLOSURE         11 <fn f>
+0072    |                     local 1
+0074    | OP_METHOD          10 'f'
+0077    | OP_POP
+0078    | OP_CLOSE_UPVALUE
+0079    | OP_NIL
+0080    | OP_RETURN
+
+== <fn outer> ==
+0000    2 OP_CONSTANT         0 '1'
+0003    3 OP_CLOSURE          1 <fn inner>
+0006    |                     local 1
+0008    4 OP_GET_LOCAL        2
+0010    | OP_RETURN
+0011    | OP_NIL
+0012    | OP_RETURN
+
+== <fn inner> ==
+0000    3 OP_GET_UPVALUE      0
+0002    | OP_RETURN
+0003    | OP_NIL
+0004    | OP_RETURN
+
+== <fn f> ==
+0000   10 OP_GET_LOCAL        0
+0002    | OP_GET_UPVALUE      0
+0004    | OP_GET_SUPER        0 'f'
+0007    | OP_GET_LOCAL        0
+0009    | OP_GET_PROPERTY     1 'x'
+0012    | OP_CALL             1
+0014    | OP_RETURN
+0015    | OP_NIL
+0016    | OP_RETURN
+`,
+	},
+}
+
+func TestDisassemble(t *testing.T) {
+	for _, test := range disassembleTests {
+		t.Run(test.name, func(t *testing.T) {
+			function, err := New(Options{Engine: BytecodeVM, Stderr: &bytes.Buffer{}}).Compile(test.source)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			var out bytes.Buffer
+			Disassemble(&out, function)
+			if out.String() != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", out.String(), test.want)
+			}
+		})
+	}
+}
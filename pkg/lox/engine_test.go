@@ -0,0 +1,257 @@
+package lox
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// engineTests run on every engine. Besides matching stdout and the error,
+// each engine must write exactly the same diagnostics as the tree walker,
+// tracebacks included.
+var engineTests = []struct {
+	name   string
+	source string
+	stdout string
+	err    string
+}{
+	// Expressions.
+	{"arithmetic", `print 1 + 2 * 3 - 4 / 2;`, "5\n", ""},
+	{"grouping", `print (1 + 2) * -(3 - 5);`, "6\n", ""},
+	{"fractions", `print 10 / 4; print 1 / 3;`, "2.5\n0.333333\n", ""},
+	{"comparison", `print 1 < 2; print 2 <= 2; print 3 > 4; print 4 >= 5;`, "true\ntrue\nfalse\nfalse\n", ""},
+	{"equality", `print 1 == 1; print "a" == "a"; print nil == false; print 1 != "1";`, "true\ntrue\nfalse\ntrue\n", ""},
+	{"truthiness", `print !nil; print !0; print !""; print !!true;`, "true\nfalse\nfalse\ntrue\n", ""},
+	{"concatenation", `var a = "con"; var b = "cat"; print a + b; print a + b == "concat";`, "concat\ntrue\n", ""},
+	{"logical", `print nil or "default"; print 1 and 2; print false and 1; print nil or false;`, "default\n2\nfalse\nfalse\n", ""},
+	{"short circuit", `fun boom() { print "boom"; return true; } print false and boom(); print true or boom();`, "false\ntrue\n", ""},
+
+	// String escapes.
+	{"escapes", `print "tab\there"; print "line\nbreak"; print "quote \"q\" and \\"; print len("\0\r");`, "tab\there\nline\nbreak\nquote \"q\" and \\\n2\n", ""},
+	{"unicode escapes", `print "\u{e9}t\u{E9}"; print "\u{1F600}"; print len("\u{1F600}");`, "été\n😀\n1\n", ""},
+	{"invalid escape", `print "a\qb";`, "", "[line 1] Error: Invalid escape sequence '\\q'."},
+	{"unicode escape without brace", `print "\u0041";`, "", "[line 1] Error: Expect '{' after '\\u'."},
+	{"empty unicode escape", `print "\u{}";`, "", "[line 1] Error: Invalid Unicode escape sequence."},
+	{"long unicode escape", `print "\u{1234567}";`, "", "[line 1] Error: Invalid Unicode escape sequence."},
+	{"unclosed unicode escape", `print "\u{41";`, "", "[line 1] Error: Invalid Unicode escape sequence."},
+	{"surrogate code point", `print "\u{D800}";`, "", "[line 1] Error: Invalid Unicode code point U+D800."},
+	{"code point out of range", `print "\u{110000}";`, "", "[line 1] Error: Invalid Unicode code point U+110000."},
+	{"every escape error", `print "\q\u{}\u{d800}";`, "", "[line 1] Error: Invalid escape sequence '\\q'.\n[line 1] Error: Invalid Unicode escape sequence.\n[line 1] Error: Invalid Unicode code point U+D800."},
+
+	// Block comments.
+	{"block comment", `print 1 /* inline */ + 2;`, "3\n", ""},
+	{"nested block comment", "/* outer /* inner */ still comment */ print 1;", "1\n", ""},
+	{"deeply nested block comment", "/* 1 /* 2 /* 3 */ 2 */ 1 */ print 2;", "2\n", ""},
+	{"multi-line block comment", "/*\n/*\n*/\n*/\nprint nope;", "", "[line 5] Error at 'nope': Undefined variable 'nope'."},
+	{"unterminated nested comment", "/* a /* b */ print 1;", "", "[line 1] Error: Unterminated block comment."},
+	{"stray comment close", "print 1; */", "", "[line 1] Error at '*': Expect expression."},
+
+	// Number literals.
+	{"hex literals", `print 0xFF; print 0Xff; print 0x0; print 0xdead_beef;`, "255\n255\n0\n3735928559\n", ""},
+	{"binary literals", `print 0b1010; print 0B1; print 0b1111_0000;`, "10\n1\n240\n", ""},
+	{"exponents", `print 1e3; print 1E-2; print 2.5e+1; print 6.02e23;`, "1000\n0.01\n25\n6.02e+23\n", ""},
+	{"digit separators", `print 1_000_000; print 3.141_592; print 1_0e1_0;`, "1000000\n3.14159\n100000000000\n", ""},
+	// An invalid number produces no token, so the parser reports the gap too.
+	{"invalid hex digit", `print 0xFG;`, "", "[line 1] Error: Invalid number: 0xFG\n[line 1] Error at ';': Expect expression."},
+	{"invalid binary digit", `print 0b102;`, "", "[line 1] Error: Invalid number: 0b102\n[line 1] Error at ';': Expect expression."},
+	{"empty hex", `print 0x;`, "", "[line 1] Error: Invalid number: 0x\n[line 1] Error at ';': Expect expression."},
+	{"doubled separator", `print 1__0;`, "", "[line 1] Error: Invalid number: 1__0\n[line 1] Error at ';': Expect expression."},
+	{"trailing separator", `print 1_;`, "", "[line 1] Error: Invalid number: 1_\n[line 1] Error at ';': Expect expression."},
+	{"separator before point", `print 1_.5;`, "", "[line 1] Error: Invalid number: 1_.5\n[line 1] Error at ';': Expect expression."},
+	{"exponent out of range", `print 1e400;`, "", "[line 1] Error: Invalid number: 1e400\n[line 1] Error at ';': Expect expression."},
+
+	// Variables and scope.
+	{"globals", `var a = 1; a = a + 1; print a; var a = "shadowed"; print a;`, "2\nshadowed\n", ""},
+	{"uninitialized", `var a; print a;`, "nil\n", ""},
+	{"blocks", `var a = "outer"; { var a = "inner"; print a; } print a;`, "inner\nouter\n", ""},
+	{"nested blocks", `{ var a = 1; { var b = 2; { var c = 3; print a + b + c; } } }`, "6\n", ""},
+	{"assignment value", `var a; var b; a = b = 3; print a; print b;`, "3\n3\n", ""},
+
+	// Control flow.
+	{"if else", `if (1 > 2) print "no"; else print "yes"; if (nil) print "no";`, "yes\n", ""},
+	{"while", `var i = 0; while (i < 3) { print i; i = i + 1; }`, "0\n1\n2\n", ""},
+	{"for", `for (var i = 0; i < 3; i = i + 1) print i;`, "0\n1\n2\n", ""},
+	{"for without clauses", `var i = 0; for (; i < 2;) i = i + 1; print i;`, "2\n", ""},
+	{"return at top level", `return;`, "", "[line 1] Error at 'return': Can't return from top-level code."},
+	{"fibonacci loop", `var a = 0; var b = 1; for (var i = 0; i < 10; i = i + 1) { var t = a; a = b; b = t + b; } print a;`, "55\n", ""},
+
+	// Functions and closures.
+	{"function", `fun greet(name) { return "hi " + name; } print greet("lox");`, "hi lox\n", ""},
+	{"implicit nil", `fun f() {} print f();`, "nil\n", ""},
+	{"print function", `fun f() {} print f; print clock;`, "<fn f>\n<native fn>\n", ""},
+	{"recursion", `fun fib(n) { if (n < 2) return n; return fib(n - 1) + fib(n - 2); } print fib(15);`, "610\n", ""},
+	{"closure counter", `
+fun makeCounter() {
+  var i = 0;
+  fun count() { i = i + 1; return i; }
+  return count;
+}
+var c = makeCounter();
+c(); c();
+print c();`, "3\n", ""},
+	{"shared upvalue", `
+var get; var set;
+{
+  var x = "before";
+  fun g() { return x; }
+  fun s(v) { x = v; }
+  get = g; set = s;
+}
+set("after");
+print get();`, "after\n", ""},
+	{"closures in loop", `
+var first; var second;
+for (var i = 0; i < 2; i = i + 1) {
+  var j = i;
+  fun f() { return j; }
+  if (i == 0) first = f; else second = f;
+}
+print first(); print second();`, "0\n1\n", ""},
+	{"nested closures", `
+fun outer() {
+  var a = "a";
+  fun middle() {
+    var b = "b";
+    fun inner() { return a + b; }
+    return inner;
+  }
+  return middle;
+}
+print outer()()();`, "ab\n", ""},
+	{"resolved binding", `
+var a = "global";
+{
+  fun show() { print a; }
+  show();
+  var a = "block";
+  show();
+}`, "global\nglobal\n", ""},
+
+	// Classes.
+	{"class", `class Box {} print Box; print Box();`, "Box\nBox instance\n", ""},
+	{"fields", `class P {} var p = P(); p.x = 1; p.y = 2; print p.x + p.y;`, "3\n", ""},
+	{"methods", `class A { hi() { return "hi " + this.name; } } var a = A(); a.name = "a"; print a.hi();`, "hi a\n", ""},
+	{"initializer", `class P { init(x, y) { this.x = x; this.y = y; } } var p = P(1, 2); print p.x * 10 + p.y;`, "12\n", ""},
+	{"init returns this", `class A { init() { this.v = 1; return; } } var a = A(); print a.init() == a;`, "true\n", ""},
+	{"bound method", `class A { init(n) { this.n = n; } get() { return this.n; } } var m = A(7).get; print m; print m();`, "<fn get>\n7\n", ""},
+	{"field shadows method", `class A { f() { return "method"; } } var a = A(); fun g() { return "field"; } a.f = g; print a.f();`, "field\n", ""},
+	{"inheritance", `class A { f() { return "A"; } } class B < A {} print B().f();`, "A\n", ""},
+	{"super", `
+class A { f() { return "A"; } }
+class B < A { f() { return "B" + super.f(); } }
+class C < B { f() { return "C" + super.f(); } }
+print C().f();`, "CBA\n", ""},
+	{"super bound", `class A { f() { return this.v; } } class B < A { g() { var m = super.f; return m(); } } var b = B(); b.v = 9; print b.g();`, "9\n", ""},
+	{"this in closure", `
+class A {
+  init() { this.v = "v"; }
+  get() { fun inner() { return this.v; } return inner; }
+}
+print A().get()();`, "v\n", ""},
+
+	// Natives.
+	{"natives", `print len("héllo"); print str(1.5) + "!"; print num("42") + 1; print substr("lox", 1, 2);`, "5\n1.5!\n43\no\n", ""},
+	{"math natives", `print sqrt(16); print floor(2.7); print abs(-3); print min(1, 2); print max(1, 2);`, "4\n2\n3\n1\n2\n", ""},
+	{"clock", `print clock() > 0;`, "true\n", ""},
+	{"substr bounds", `print substr("abc", 0, 3); print substr("abc", 3, 3);`, "abc\n\n", ""},
+	{"substr huge end", `print substr("abc", 0, 1e20);`, "", "[line 1] Error at ')': substr() range [0, 1e+20) is out of bounds for length 3."},
+	{"substr end past length", `print substr("abc", 1, 4);`, "", "[line 1] Error at ')': substr() range [1, 4) is out of bounds for length 3."},
+	{"substr negative start", `print substr("abc", -1, 2);`, "", "[line 1] Error at ')': substr() range [-1, 2) is out of bounds for length 3."},
+	{"substr huge start", `print substr("abc", 1e20, 1e20);`, "", "[line 1] Error at ')': substr() range [1e+20, 1e+20) is out of bounds for length 3."},
+	{"substr start after end", `print substr("abc", 2, 1);`, "", "[line 1] Error at ')': substr() range [2, 1) is out of bounds for length 3."},
+
+	// Compile errors.
+	{"unterminated string", "print 1;\nprint \"abc\ndef;\n", "", "[line 2] Error: Unterminated string.\n[line 4] Error at end: Expect expression."},
+	{"escape error in multi-line string", "print \"a\nb\\q\";", "", "[line 2] Error: Invalid escape sequence '\\q'."},
+	{"unterminated block comment", "print 1;\n/* a\n/* b */\n", "", "[line 2] Error: Unterminated block comment."},
+	{"syntax error", `print 1 +;`, "", "[line 1] Error at ';': Expect expression."},
+	{"self initializer", `{ var a = a; }`, "", "[line 1] Error at 'a': Can't read local variable in its own initializer."},
+	{"redeclared local", `{ var a = 1; var a = 2; }`, "", "[line 1] Error at 'a': Already a variable with this name in this scope."},
+	{"this outside class", `print this;`, "", "[line 1] Error at 'this': Can't use 'this' outside of a class."},
+	{"return from init", `class A { init() { return 1; } }`, "", "[line 1] Error at 'return': Can't return a value from an initializer."},
+	{"inherit self", `class A < A {}`, "", "[line 1] Error at 'A': A class can't inherit from itself."},
+
+	// The parser recovers at statement boundaries and reports every error.
+	{"parse errors", "print 1 +;\nvar = 2;\nprint (3;\nclass { }\nprint 4;\nfun f(a, ) {}\n", "", "[line 1] Error at ';': Expect expression.\n[line 2] Error at '=': Expect variable name.\n[line 3] Error at ';': Expect ')' after expression.\n[line 4] Error at '{': Expect class name.\n[line 6] Error at ')': Expect parameter name."},
+	{"parse errors in blocks", "{\n  print ;\n  print 2;\n}\nfun f() {\n  return 1 1;\n}\nvar x = ;", "", "[line 2] Error at ';': Expect expression.\n[line 6] Error at '1': Expect ';' after return value.\n[line 8] Error at ';': Expect expression."},
+	{"parse error at end", "print (1", "", "[line 1] Error at end: Expect ')' after expression."},
+	{"parse and resolve errors", "print 1 +;\n{ var a = a; }", "", "[line 1] Error at ';': Expect expression."},
+	{"every resolve error", "return 1;\nprint this;\n{ var a = 1; var a = 2; }", "", "[line 1] Error at 'return': Can't return from top-level code.\n[line 2] Error at 'this': Can't use 'this' outside of a class.\n[line 3] Error at 'a': Already a variable with this name in this scope."},
+
+	// Runtime errors.
+	{"undefined variable", "print 1;\nprint nope;", "1\n", "[line 2] Error at 'nope': Undefined variable 'nope'."},
+	{"undefined assignment", `nope = 1;`, "", "[line 1] Error at 'nope': Undefined variable 'nope'."},
+	{"operand types", `print "a" - 1;`, "", "[line 1] Error at '-': Operands must be numbers."},
+	{"add types", `print "a" + 1;`, "", "[line 1] Error at '+': Operands must be two numbers or two strings."},
+	{"negate", `print -"a";`, "", "[line 1] Error at '-': Operand must be a number."},
+	{"call non callable", `"a"();`, "", "[line 1] Error at ')': Can only call functions and classes."},
+	{"arity", `fun f(a) {} f(1, 2);`, "", "[line 1] Error at ')': Expected 1 arguments but got 2."},
+	{"undefined property", `class A {} print A().x;`, "", "[line 1] Error at 'x': Undefined property 'x'."},
+	{"property on non instance", `print "a".x;`, "", "[line 1] Error at 'x': Only instances have properties."},
+	{"superclass not class", `var A = 1; class B < A {}`, "", "[line 1] Error at 'A': Superclass must be a class."},
+	{"native error", `len(1);`, "", "[line 1] Error at ')': len() argument 1 must be a string."},
+	{"traceback", `
+fun inner() { return 1 + nil; }
+fun outer() { return inner(); }
+outer();`, "", "[line 2] Error at '+': Operands must be two numbers or two strings."},
+	{"method traceback", `
+class A {
+  f() { return this.missing; }
+}
+fun call(a) { a.f(); }
+call(A());`, "", "[line 3] Error at 'missing': Undefined property 'missing'."},
+	{"stack overflow", `fun f() { f(); } f();`, "", "[line 1] Error at ')': Stack overflow."},
+
+	// Programs that allocate enough for the collector to run mid-flight.
+	{"garbage strings", `
+var s = "";
+for (var i = 0; i < 200; i = i + 1) s = s + "x";
+print len(s);`, "200\n", ""},
+	{"garbage instances", `
+class Node { init(next) { this.next = next; } }
+var head = nil;
+for (var i = 0; i < 100; i = i + 1) head = Node(head);
+var n = 0;
+while (head != nil) { n = n + 1; head = head.next; }
+print n;`, "100\n", ""},
+	{"garbage closures", `
+fun adder(n) { fun add(x) { return x + n; } return add; }
+var total = 0;
+for (var i = 0; i < 100; i = i + 1) total = adder(i)(total);
+print total;`, "4950\n", ""},
+	{"garbage methods", `
+class A { init(s) { this.s = s; } twice() { return this.s + this.s; } }
+var out = "";
+for (var i = 0; i < 50; i = i + 1) out = A(str(i)).twice();
+print out;`, "4949\n", ""},
+}
+
+func TestEngines(t *testing.T) {
+	for _, test := range engineTests {
+		t.Run(test.name, func(t *testing.T) {
+			var wantStderr string
+			for _, engine := range engines {
+				var stdout, stderr bytes.Buffer
+				options := engine.options
+				options.Stdout, options.Stderr = &stdout, &stderr
+				err := New(options).Run(context.Background(), test.source)
+
+				if stdout.String() != test.stdout {
+					t.Errorf("%s: stdout = %q, want %q", engine.name, stdout.String(), test.stdout)
+				}
+				gotErr := ""
+				if err != nil {
+					gotErr = err.Error()
+				}
+				if gotErr != test.err {
+					t.Errorf("%s: error = %q, want %q", engine.name, gotErr, test.err)
+				}
+				if engine.options.Engine == TreeWalker {
+					wantStderr = stderr.String()
+				} else if stderr.String() != wantStderr {
+					t.Errorf("%s: stderr differs from the tree walker:\n%s\nwant:\n%s", engine.name, stderr.String(), wantStderr)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package lox
+
+import "fmt"
+
+// Environment maps variable names to values for a single scope. Lookups that
+// miss fall through to the enclosing scope, up to the globals. Names are
+// interned Symbols, so a lookup hashes a pointer rather than a string.
+type Environment struct {
+	values    map[*Symbol]interface{}
+	enclosing *Environment
+}
+
+func NewEnvironment(enclosing *Environment) *Environment {
+	return &Environment{
+		values:    map[*Symbol]interface{}{},
+		enclosing: enclosing,
+	}
+}
+
+func (e *Environment) Define(name *Symbol, value interface{}) {
+	e.values[name] = value
+}
+
+func (e *Environment) Get(name Token) (interface{}, error) {
+	if value, ok := e.values[name.Symbol]; ok {
+		return value, nil
+	}
+	if e.enclosing != nil {
+		return e.enclosing.Get(name)
+	}
+	return nil, undefinedVariable(name)
+}
+
+func (e *Environment) Assign(name Token, value interface{}) error {
+	if _, ok := e.values[name.Symbol]; ok {
+		e.values[name.Symbol] = value
+		return nil
+	}
+	if e.enclosing != nil {
+		return e.enclosing.Assign(name, value)
+	}
+	return undefinedVariable(name)
+}
+
+func (e *Environment) GetAt(distance int, name *Symbol) interface{} {
+	return e.ancestor(distance).values[name]
+}
+
+func (e *Environment) AssignAt(distance int, name Token, value interface{}) {
+	e.ancestor(distance).values[name.Symbol] = value
+}
+
+func (e *Environment) ancestor(distance int) *Environment {
+	env := e
+	for i := 0; i < distance; i++ {
+		env = env.enclosing
+	}
+	return env
+}
+
+func undefinedVariable(name Token) *RuntimeError {
+	return &RuntimeError{Message: fmt.Sprintf("Undefined variable '%s'.", name.Lexeme), Token: name}
+}
@@ -0,0 +1,583 @@
+package lox
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/codecrafters-io/interpreter-starter-go/pkg/diagnostics"
+)
+
+type Evaluator struct {
+	AST         *AST
+	globals     *Environment
+	environment *Environment
+	locals      map[Expr]int
+	symbols     *SymbolTable
+	stdin       *bufio.Reader
+	stdout      io.Writer
+	ctx         context.Context
+	// callStack holds the calls in progress, outermost first, for
+	// tracebacks. Its length is capped like the VM's frame limit so runaway
+	// recursion is reported instead of exhausting the Go stack.
+	callStack []activeCall
+}
+
+// activeCall is a function call in progress and the line it was made from.
+type activeCall struct {
+	function string
+	line     int
+}
+
+type RuntimeError struct {
+	Message string
+	Token   Token
+	// Trace is the traceback, innermost first. It is empty for errors
+	// raised outside any function call.
+	Trace []diagnostics.Frame
+}
+
+func (e *RuntimeError) Error() string {
+	return e.Diagnostic().Error()
+}
+
+func (e *RuntimeError) Diagnostic() *diagnostics.Diagnostic {
+	if e.Token.Line == 0 {
+		return &diagnostics.Diagnostic{Severity: diagnostics.Error, Message: e.Message, Trace: e.Trace}
+	}
+	d := e.Token.errorAt(e.Message)
+	d.Trace = e.Trace
+	return d
+}
+
+// NewEvaluator returns an evaluator for ast, whose identifiers must have
+// been scanned against symbols.
+func NewEvaluator(ast *AST, symbols *SymbolTable) *Evaluator {
+	globals := NewEnvironment(nil)
+	defineNatives(globals, symbols)
+	return &Evaluator{
+		AST:         ast,
+		globals:     globals,
+		environment: globals,
+		locals:      map[Expr]int{},
+		symbols:     symbols,
+		stdin:       bufio.NewReader(os.Stdin),
+		stdout:      os.Stdout,
+		ctx:         context.Background(),
+	}
+}
+
+func (e *Evaluator) Evaluate() (interface{}, error) {
+	var results []interface{}
+	for _, node := range e.AST.Nodes {
+		res, err := e.evaluateExpr(node)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// Run executes every statement in AST.Statements for its side effects.
+func (e *Evaluator) Run() error {
+	for _, stmt := range e.AST.Statements {
+		if err := e.execute(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Evaluator) execute(stmt Stmt) error {
+	switch stmt := stmt.(type) {
+	case PrintStmt:
+		return e.executePrint(&stmt)
+	case ExpressionStmt:
+		return e.executeExpression(&stmt)
+	case VarStmt:
+		return e.executeVar(&stmt)
+	case BlockStmt:
+		return e.executeBlock(stmt.Statements, NewEnvironment(e.environment))
+	case IfStmt:
+		return e.executeIf(&stmt)
+	case WhileStmt:
+		return e.executeWhile(&stmt)
+	case FunctionStmt:
+		return e.executeFunction(&stmt)
+	case ReturnStmt:
+		return e.executeReturn(&stmt)
+	case ClassStmt:
+		return e.executeClass(&stmt)
+	default:
+		log.Printf("Unknown statement type: %T", stmt)
+		return &RuntimeError{Message: "Unknown statement type", Token: Token{}}
+	}
+}
+
+func (e *Evaluator) executePrint(stmt *PrintStmt) error {
+	value, err := e.evaluateExpr(stmt.Expression)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(e.stdout, Stringify(value))
+	return nil
+}
+
+func (e *Evaluator) executeExpression(stmt *ExpressionStmt) error {
+	_, err := e.evaluateExpr(stmt.Expression)
+	return err
+}
+
+func (e *Evaluator) executeVar(stmt *VarStmt) error {
+	var value interface{}
+	if stmt.Initializer != nil {
+		v, err := e.evaluateExpr(stmt.Initializer)
+		if err != nil {
+			return err
+		}
+		value = v
+	}
+	e.environment.Define(stmt.Name.Symbol, value)
+	return nil
+}
+
+// executeBlock runs statements inside env, restoring the current environment
+// afterwards even if one of them fails.
+func (e *Evaluator) executeBlock(statements []Stmt, env *Environment) error {
+	previous := e.environment
+	e.environment = env
+	defer func() { e.environment = previous }()
+
+	for _, stmt := range statements {
+		if err := e.execute(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Evaluator) executeIf(stmt *IfStmt) error {
+	condition, err := e.evaluateExpr(stmt.Condition)
+	if err != nil {
+		return err
+	}
+	if isTruthy(condition) {
+		return e.execute(stmt.ThenBranch)
+	}
+	if stmt.ElseBranch != nil {
+		return e.execute(stmt.ElseBranch)
+	}
+	return nil
+}
+
+func (e *Evaluator) executeWhile(stmt *WhileStmt) error {
+	for {
+		if err := e.ctx.Err(); err != nil {
+			return err
+		}
+		condition, err := e.evaluateExpr(stmt.Condition)
+		if err != nil {
+			return err
+		}
+		if !isTruthy(condition) {
+			return nil
+		}
+		if err := e.execute(stmt.Body); err != nil {
+			return err
+		}
+	}
+}
+
+func (e *Evaluator) executeFunction(stmt *FunctionStmt) error {
+	e.environment.Define(stmt.Name.Symbol, &LoxFunction{Declaration: stmt, Closure: e.environment})
+	return nil
+}
+
+func (e *Evaluator) executeClass(stmt *ClassStmt) error {
+	var superclass *LoxClass
+	if stmt.Superclass != nil {
+		value, err := e.evaluateExpr(stmt.Superclass)
+		if err != nil {
+			return err
+		}
+		class, ok := value.(*LoxClass)
+		if !ok {
+			return &RuntimeError{Message: "Superclass must be a class.", Token: stmt.Superclass.Name}
+		}
+		superclass = class
+	}
+
+	e.environment.Define(stmt.Name.Symbol, nil)
+
+	if superclass != nil {
+		e.environment = NewEnvironment(e.environment)
+		e.environment.Define(superSymbol, superclass)
+	}
+
+	methods := map[*Symbol]*LoxFunction{}
+	for i := range stmt.Methods {
+		method := &stmt.Methods[i]
+		methods[method.Name.Symbol] = &LoxFunction{
+			Declaration:   method,
+			Closure:       e.environment,
+			IsInitializer: method.Name.Symbol == initSymbol,
+		}
+	}
+
+	class := &LoxClass{Name: stmt.Name.Lexeme, Superclass: superclass, Methods: methods}
+	if superclass != nil {
+		e.environment = e.environment.enclosing
+	}
+	return e.environment.Assign(stmt.Name, class)
+}
+
+func (e *Evaluator) executeReturn(stmt *ReturnStmt) error {
+	var value interface{}
+	if stmt.Value != nil {
+		v, err := e.evaluateExpr(stmt.Value)
+		if err != nil {
+			return err
+		}
+		value = v
+	}
+	return &returnValue{Value: value}
+}
+
+func (e *Evaluator) evaluateExpr(expr Expr) (interface{}, error) {
+	switch expr := expr.(type) {
+	case BinaryExpr:
+		return e.evaluateBinary(&expr)
+	case Grouping:
+		return e.evaluateGrouping(&expr)
+	case Literal:
+		return e.evaluateLiteral(&expr)
+	case UnaryExpr:
+		return e.evaluateUnary(&expr)
+	case LogicalExpr:
+		return e.evaluateLogical(&expr)
+	case *AssignExpr:
+		return e.evaluateAssign(expr)
+	case *VariableExpr:
+		return e.evaluateVariable(expr)
+	case CallExpr:
+		return e.evaluateCall(&expr)
+	case GetExpr:
+		return e.evaluateGet(&expr)
+	case SetExpr:
+		return e.evaluateSet(&expr)
+	case *ThisExpr:
+		return e.lookUpVariable(expr.Keyword, expr)
+	case *SuperExpr:
+		return e.evaluateSuper(expr)
+	default:
+		log.Printf("Unknown expression type: %T", expr)
+		return nil, &RuntimeError{Message: "Unknown expression type", Token: Token{}}
+	}
+}
+
+func checkNumOp(operator Token, op interface{}) (float64, error) {
+	if v, ok := op.(float64); ok {
+		return v, nil
+	}
+	return 0, &RuntimeError{Message: "Operand must be a number.", Token: operator}
+}
+
+func checkNumOps(operator Token, left, right interface{}) (float64, float64, error) {
+	leftNum, leftOk := left.(float64)
+	rightNum, rightOk := right.(float64)
+	if !leftOk || !rightOk {
+		return 0, 0, &RuntimeError{Message: "Operands must be numbers.", Token: operator}
+	}
+	return leftNum, rightNum, nil
+}
+
+func (e *Evaluator) evaluateBinary(expr *BinaryExpr) (interface{}, error) {
+	if expr.Left == nil || expr.Right == nil {
+		return nil, &RuntimeError{Message: "Invalid binary expression", Token: expr.Operator}
+	}
+	left, err := e.evaluateExpr(expr.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.evaluateExpr(expr.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch expr.Operator.Type {
+	case TokenMap["+"]:
+		if isString(left) && isString(right) {
+			return left.(string) + right.(string), nil
+		}
+		if isNumber(left) && isNumber(right) {
+			return left.(float64) + right.(float64), nil
+		}
+		return nil, &RuntimeError{Message: "Operands must be two numbers or two strings.", Token: expr.Operator}
+	case TokenMap["-"]:
+		leftNum, rightNum, err := checkNumOps(expr.Operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		return leftNum - rightNum, nil
+	case TokenMap["/"]:
+		leftNum, rightNum, err := checkNumOps(expr.Operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		if rightNum == 0 {
+			return nil, &RuntimeError{Message: "Division by zero", Token: expr.Operator}
+		}
+		return leftNum / rightNum, nil
+	case TokenMap["*"]:
+		leftNum, rightNum, err := checkNumOps(expr.Operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		return leftNum * rightNum, nil
+	case TokenMap[">"]:
+		leftNum, rightNum, err := checkNumOps(expr.Operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		return leftNum > rightNum, nil
+	case TokenMap[">="]:
+		leftNum, rightNum, err := checkNumOps(expr.Operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		return leftNum >= rightNum, nil
+	case TokenMap["<"]:
+		leftNum, rightNum, err := checkNumOps(expr.Operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		return leftNum < rightNum, nil
+	case TokenMap["<="]:
+		leftNum, rightNum, err := checkNumOps(expr.Operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		return leftNum <= rightNum, nil
+	case TokenMap["!="]:
+		return !isEqual(left, right), nil
+	case TokenMap["=="]:
+		return isEqual(left, right), nil
+	}
+	return nil, nil
+}
+
+func (e *Evaluator) evaluateGrouping(expr *Grouping) (interface{}, error) {
+	return e.evaluateExpr(expr.Expression)
+}
+
+func (e *Evaluator) evaluateLiteral(expr *Literal) (interface{}, error) {
+	switch v := expr.Value.(type) {
+	case float64, string, bool, nil:
+		return v, nil
+	default:
+		return nil, &RuntimeError{Message: "Unknown literal type", Token: Token{}}
+	}
+}
+
+func (e *Evaluator) evaluateUnary(expr *UnaryExpr) (interface{}, error) {
+	right, err := e.evaluateExpr(expr.Right)
+	if err != nil {
+		return nil, err
+	}
+	switch expr.Operator.Type {
+	case TokenMap["-"]:
+		rightNum, err := checkNumOp(expr.Operator, right)
+		if err != nil {
+			return nil, err
+		}
+		return -rightNum, nil
+	case TokenMap["!"]:
+		return !isTruthy(right), nil
+	default:
+		return nil, &RuntimeError{Message: "Unknown unary operator", Token: expr.Operator}
+	}
+}
+
+// evaluateLogical only evaluates the right operand when the left one doesn't
+// already decide the result, and yields the deciding operand itself rather
+// than a bool.
+func (e *Evaluator) evaluateLogical(expr *LogicalExpr) (interface{}, error) {
+	left, err := e.evaluateExpr(expr.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	if expr.Operator.Type == "OR" {
+		if isTruthy(left) {
+			return left, nil
+		}
+	} else if !isTruthy(left) {
+		return left, nil
+	}
+	return e.evaluateExpr(expr.Right)
+}
+
+func (e *Evaluator) evaluateAssign(expr *AssignExpr) (interface{}, error) {
+	v, err := e.evaluateExpr(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if distance, ok := e.locals[expr]; ok {
+		e.environment.AssignAt(distance, expr.Name, v)
+		return v, nil
+	}
+	if err := e.globals.Assign(expr.Name, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (e *Evaluator) evaluateVariable(expr *VariableExpr) (interface{}, error) {
+	return e.lookUpVariable(expr.Name, expr)
+}
+
+// lookUpVariable reads a name using the scope depth computed by the resolver,
+// falling back to the globals for anything it left unresolved.
+func (e *Evaluator) lookUpVariable(name Token, expr Expr) (interface{}, error) {
+	if distance, ok := e.locals[expr]; ok {
+		return e.environment.GetAt(distance, name.Symbol), nil
+	}
+	return e.globals.Get(name)
+}
+
+// Resolve records how many scopes separate expr from the scope declaring the
+// variable it refers to.
+func (e *Evaluator) Resolve(expr Expr, depth int) {
+	e.locals[expr] = depth
+}
+
+func (e *Evaluator) evaluateCall(expr *CallExpr) (interface{}, error) {
+	callee, err := e.evaluateExpr(expr.Callee)
+	if err != nil {
+		return nil, err
+	}
+
+	var arguments []interface{}
+	for _, arg := range expr.Arguments {
+		v, err := e.evaluateExpr(arg)
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, v)
+	}
+
+	if err := e.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	function, ok := callee.(LoxCallable)
+	if !ok {
+		return nil, &RuntimeError{Message: "Can only call functions and classes.", Token: expr.Paren}
+	}
+	if len(arguments) != function.Arity() {
+		return nil, &RuntimeError{
+			Message: fmt.Sprintf("Expected %d arguments but got %d.", function.Arity(), len(arguments)),
+			Token:   expr.Paren,
+		}
+	}
+	// Natives get no frame of their own: their errors are reported at the
+	// call site, in the caller.
+	if native, ok := function.(*NativeFunction); ok {
+		result, err := native.Call(e, arguments)
+		if err != nil {
+			if _, ok := err.(*RuntimeError); !ok {
+				return nil, &RuntimeError{Message: err.Error(), Token: expr.Paren}
+			}
+			return nil, err
+		}
+		return result, nil
+	}
+
+	if len(e.callStack) == maxFrames {
+		return nil, &RuntimeError{Message: "Stack overflow.", Token: expr.Paren}
+	}
+	e.callStack = append(e.callStack, activeCall{function: callableName(function), line: expr.Paren.Line})
+	result, err := function.Call(e, arguments)
+	if runtimeErr, ok := err.(*RuntimeError); ok && runtimeErr.Trace == nil {
+		runtimeErr.Trace = e.traceback(runtimeErr.Token.Line)
+	}
+	e.callStack = e.callStack[:len(e.callStack)-1]
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// traceback describes the call stack for an error raised on line in the
+// innermost call: each call is reported at the line executing in it, which
+// for all but the innermost is the line of the call it made.
+func (e *Evaluator) traceback(line int) []diagnostics.Frame {
+	frames := make([]diagnostics.Frame, 0, len(e.callStack)+1)
+	for i := len(e.callStack) - 1; i >= 0; i-- {
+		frames = append(frames, diagnostics.Frame{Line: line, Function: e.callStack[i].function})
+		line = e.callStack[i].line
+	}
+	return append(frames, diagnostics.Frame{Line: line})
+}
+
+// callableName is how a call appears in a traceback. Calling a class runs
+// its initializer.
+func callableName(callee LoxCallable) string {
+	switch c := callee.(type) {
+	case *LoxFunction:
+		return c.Declaration.Name.Lexeme
+	case *LoxClass:
+		return "init"
+	default:
+		return fmt.Sprint(callee)
+	}
+}
+
+func (e *Evaluator) evaluateGet(expr *GetExpr) (interface{}, error) {
+	object, err := e.evaluateExpr(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+	instance, ok := object.(*LoxInstance)
+	if !ok {
+		return nil, &RuntimeError{Message: "Only instances have properties.", Token: expr.Name}
+	}
+	return instance.Get(expr.Name)
+}
+
+func (e *Evaluator) evaluateSet(expr *SetExpr) (interface{}, error) {
+	object, err := e.evaluateExpr(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+	instance, ok := object.(*LoxInstance)
+	if !ok {
+		return nil, &RuntimeError{Message: "Only instances have fields.", Token: expr.Name}
+	}
+
+	value, err := e.evaluateExpr(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+	instance.Set(expr.Name, value)
+	return value, nil
+}
+
+// evaluateSuper finds the method on the superclass captured when the class
+// was declared and binds it to the "this" one scope further in.
+func (e *Evaluator) evaluateSuper(expr *SuperExpr) (interface{}, error) {
+	distance := e.locals[expr]
+	superclass := e.environment.GetAt(distance, superSymbol).(*LoxClass)
+	object := e.environment.GetAt(distance-1, thisSymbol).(*LoxInstance)
+
+	method := superclass.FindMethod(expr.Method.Symbol)
+	if method == nil {
+		return nil, &RuntimeError{Message: fmt.Sprintf("Undefined property '%s'.", expr.Method.Lexeme), Token: expr.Method}
+	}
+	return method.Bind(object), nil
+}
@@ -0,0 +1,67 @@
+package lox
+
+import "fmt"
+
+// LoxCallable is implemented by every runtime value that can appear on the
+// left of a call expression.
+type LoxCallable interface {
+	Arity() int
+	Call(e *Evaluator, arguments []interface{}) (interface{}, error)
+}
+
+// LoxFunction is a user-defined function together with the environment it
+// was declared in, so inner functions keep seeing their enclosing locals
+// after the outer call has returned.
+type LoxFunction struct {
+	Declaration   *FunctionStmt
+	Closure       *Environment
+	IsInitializer bool
+}
+
+func (f *LoxFunction) Arity() int {
+	return len(f.Declaration.Params)
+}
+
+func (f *LoxFunction) Call(e *Evaluator, arguments []interface{}) (interface{}, error) {
+	env := NewEnvironment(f.Closure)
+	for i, param := range f.Declaration.Params {
+		env.Define(param.Symbol, arguments[i])
+	}
+
+	err := e.executeBlock(f.Declaration.Body, env)
+	if ret, ok := err.(*returnValue); ok {
+		if f.IsInitializer {
+			return f.Closure.GetAt(0, thisSymbol), nil
+		}
+		return ret.Value, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if f.IsInitializer {
+		return f.Closure.GetAt(0, thisSymbol), nil
+	}
+	return nil, nil
+}
+
+// Bind returns a copy of the method whose closure has "this" bound to
+// instance.
+func (f *LoxFunction) Bind(instance *LoxInstance) *LoxFunction {
+	env := NewEnvironment(f.Closure)
+	env.Define(thisSymbol, instance)
+	return &LoxFunction{Declaration: f.Declaration, Closure: env, IsInitializer: f.IsInitializer}
+}
+
+func (f *LoxFunction) String() string {
+	return fmt.Sprintf("<fn %s>", f.Declaration.Name.Lexeme)
+}
+
+// returnValue is threaded back through execute as an error so that a return
+// statement unwinds every enclosing block and loop up to LoxFunction.Call.
+type returnValue struct {
+	Value interface{}
+}
+
+func (r *returnValue) Error() string {
+	return "return outside of function"
+}
@@ -0,0 +1,250 @@
+package lox
+
+import "unsafe"
+
+// The VM manages its own heap: every object is linked into vm.objects when
+// allocated and a tri-color mark-and-sweep collector unlinks the ones the
+// program can no longer reach, after which Go reclaims them. Objects start
+// white, turn gray when found reachable and black once everything they
+// refer to has been marked too.
+//
+// A collection runs before an allocation pushes the heap past nextGC, so
+// anything the VM is in the middle of building must already be reachable
+// from a root: the value stack, the call frames, the globals, the open
+// upvalues and the functions being compiled.
+
+const (
+	initialGCThreshold = 1 << 20
+	heapGrowFactor     = 2
+
+	// fieldSize is what one instance field or class method is accounted
+	// for: a map entry's key and value.
+	fieldSize = int(unsafe.Sizeof("")) + int(unsafe.Sizeof(interface{}(nil)))
+)
+
+// newString returns the interned string for chars, allocating it only if
+// the VM has none yet.
+func (vm *VM) newString(chars string) *ObjString {
+	if s, ok := vm.strings[chars]; ok {
+		return s
+	}
+	s := &ObjString{Chars: chars}
+	vm.track(s, int(unsafe.Sizeof(*s))+len(chars))
+	vm.strings[chars] = s
+	return s
+}
+
+func (vm *VM) newFunction(name string) *ObjFunction {
+	f := &ObjFunction{Name: name}
+	vm.track(f, int(unsafe.Sizeof(*f)))
+	return f
+}
+
+func (vm *VM) newUpvalue(slot int, next *ObjUpvalue) *ObjUpvalue {
+	u := &ObjUpvalue{Slot: slot, Next: next}
+	vm.track(u, int(unsafe.Sizeof(*u)))
+	return u
+}
+
+func (vm *VM) newClosure(function *ObjFunction) *ObjClosure {
+	c := &ObjClosure{Function: function, Upvalues: make([]*ObjUpvalue, function.UpvalueCount)}
+	vm.track(c, int(unsafe.Sizeof(*c))+function.UpvalueCount*int(unsafe.Sizeof(c)))
+	return c
+}
+
+func (vm *VM) newClass(name string) *ObjClass {
+	c := &ObjClass{Name: name, Methods: map[*ObjString]*ObjClosure{}}
+	vm.track(c, int(unsafe.Sizeof(*c)))
+	return c
+}
+
+func (vm *VM) newInstance(class *ObjClass) *ObjInstance {
+	i := &ObjInstance{Class: class, Fields: map[*ObjString]interface{}{}}
+	vm.track(i, int(unsafe.Sizeof(*i)))
+	return i
+}
+
+func (vm *VM) newBoundMethod(receiver interface{}, method *ObjClosure) *ObjBoundMethod {
+	b := &ObjBoundMethod{Receiver: receiver, Method: method}
+	vm.track(b, int(unsafe.Sizeof(*b)))
+	return b
+}
+
+// track accounts for a new object and links it into the heap, collecting
+// first if the heap has outgrown its threshold.
+func (vm *VM) track(obj Obj, size int) {
+	vm.bytesAllocated += size
+	if vm.stressGC || vm.bytesAllocated > vm.nextGC {
+		vm.collectGarbage()
+	}
+	h := obj.header()
+	h.size = size
+	h.next = vm.objects
+	vm.objects = obj
+}
+
+// grow accounts for an object that got bigger after it was allocated.
+func (vm *VM) grow(obj Obj, size int) {
+	obj.header().size += size
+	vm.bytesAllocated += size
+}
+
+func (vm *VM) collectGarbage() {
+	vm.markRoots()
+	vm.traceReferences()
+	vm.removeWhiteStrings()
+	vm.sweep()
+
+	vm.nextGC = vm.bytesAllocated * heapGrowFactor
+	if vm.nextGC < initialGCThreshold {
+		vm.nextGC = initialGCThreshold
+	}
+}
+
+func (vm *VM) markRoots() {
+	for _, value := range vm.stack[:vm.sp] {
+		vm.markValue(value)
+	}
+	for i := 0; i < vm.frameCount; i++ {
+		vm.markObject(vm.frames[i].closure)
+	}
+	for upvalue := vm.openUpvalues; upvalue != nil; upvalue = upvalue.Next {
+		vm.markObject(upvalue)
+	}
+	for name, value := range vm.globals {
+		vm.markObject(name)
+		vm.markValue(value)
+	}
+	vm.markObject(vm.initString)
+	if vm.compiler != nil {
+		for fc := vm.compiler.current; fc != nil; fc = fc.enclosing {
+			vm.markObject(fc.function)
+		}
+	}
+}
+
+func (vm *VM) markValue(value interface{}) {
+	if obj, ok := value.(Obj); ok {
+		vm.markObject(obj)
+	}
+}
+
+// markObject turns a white object gray.
+func (vm *VM) markObject(obj Obj) {
+	if obj == nil || obj.header().marked {
+		return
+	}
+	obj.header().marked = true
+	vm.grayStack = append(vm.grayStack, obj)
+}
+
+// traceReferences blackens gray objects until none are left.
+func (vm *VM) traceReferences() {
+	for len(vm.grayStack) > 0 {
+		obj := vm.grayStack[len(vm.grayStack)-1]
+		vm.grayStack = vm.grayStack[:len(vm.grayStack)-1]
+		obj.blacken(vm)
+	}
+}
+
+// removeWhiteStrings drops unreachable strings from the intern table. The
+// table doesn't keep strings alive; otherwise every string ever created
+// would survive.
+func (vm *VM) removeWhiteStrings() {
+	for chars, s := range vm.strings {
+		if !s.marked {
+			delete(vm.strings, chars)
+		}
+	}
+}
+
+// sweep unlinks every object left white and clears the marks of the rest
+// for the next cycle.
+func (vm *VM) sweep() {
+	var prev Obj
+	obj := vm.objects
+	for obj != nil {
+		h := obj.header()
+		if h.marked {
+			h.marked = false
+			prev = obj
+			obj = h.next
+			continue
+		}
+
+		unreached := obj
+		obj = h.next
+		if prev == nil {
+			vm.objects = obj
+		} else {
+			prev.header().next = obj
+		}
+		vm.bytesAllocated -= h.size
+		h.next = nil
+		h.freed = true
+		if vm.stressGC {
+			poison(unreached)
+		}
+	}
+}
+
+// poison clears a swept object's references so that, under --stress-gc,
+// any use of an object the collector wrongly considered unreachable fails
+// loudly instead of silently working.
+func poison(obj Obj) {
+	switch o := obj.(type) {
+	case *ObjFunction:
+		o.Chunk = Chunk{}
+	case *ObjUpvalue:
+		o.Closed = nil
+	case *ObjClosure:
+		o.Function, o.Upvalues = nil, nil
+	case *ObjClass:
+		o.Methods = nil
+	case *ObjInstance:
+		o.Class, o.Fields = nil, nil
+	case *ObjBoundMethod:
+		o.Receiver, o.Method = nil, nil
+	}
+}
+
+func (s *ObjString) blacken(vm *VM) {}
+
+func (f *ObjFunction) blacken(vm *VM) {
+	for _, constant := range f.Chunk.Constants {
+		vm.markValue(constant)
+	}
+}
+
+func (u *ObjUpvalue) blacken(vm *VM) {
+	vm.markValue(u.Closed)
+}
+
+func (c *ObjClosure) blacken(vm *VM) {
+	vm.markObject(c.Function)
+	for _, upvalue := range c.Upvalues {
+		if upvalue != nil {
+			vm.markObject(upvalue)
+		}
+	}
+}
+
+func (c *ObjClass) blacken(vm *VM) {
+	for name, method := range c.Methods {
+		vm.markObject(name)
+		vm.markObject(method)
+	}
+}
+
+func (i *ObjInstance) blacken(vm *VM) {
+	vm.markObject(i.Class)
+	for name, value := range i.Fields {
+		vm.markObject(name)
+		vm.markValue(value)
+	}
+}
+
+func (b *ObjBoundMethod) blacken(vm *VM) {
+	vm.markValue(b.Receiver)
+	vm.markObject(b.Method)
+}
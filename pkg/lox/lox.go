@@ -0,0 +1,355 @@
+// Package lox is an embeddable interpreter for the Lox language.
+//
+// Most programs only need New and Interpreter.Run:
+//
+//	interp := lox.New(lox.Options{Stdout: &buf})
+//	interp.RegisterFunc("double", 1, func(args []interface{}) (interface{}, error) {
+//		return args[0].(float64) * 2, nil
+//	})
+//	err := interp.Run(ctx, `print double(21);`)
+//
+// The Scanner, Parser, Resolver and Evaluator stages are exported as well
+// for tools that need tokens or syntax trees.
+package lox
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/codecrafters-io/interpreter-starter-go/pkg/diagnostics"
+)
+
+// Options configures an Interpreter. Nil writers and readers fall back to the
+// process's standard streams. Pass a *bufio.Reader as Stdin to keep reading
+// from it yourself without input() buffering ahead of you.
+type Options struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	// Stderr receives a rendering of every diagnostic Run and Eval report.
+	// Use io.Discard to rely on the returned error alone.
+	Stderr           io.Writer
+	DiagnosticFormat diagnostics.Format
+	Engine           Engine
+	// Trace, if set, receives a listing of the VM's stack and every
+	// instruction it executes. It has no effect on the TreeWalker engine.
+	Trace io.Writer
+	// StressGC makes the VM collect garbage before every allocation. It is
+	// slow and only meant for testing the collector.
+	StressGC bool
+}
+
+// Engine selects how programs are executed. Both engines produce the same
+// output and errors.
+type Engine int
+
+const (
+	// TreeWalker evaluates the syntax tree directly.
+	TreeWalker Engine = iota
+	// BytecodeVM compiles to bytecode and runs it on a stack machine.
+	BytecodeVM
+)
+
+// ErrorKind tells whether a program was rejected before running or failed
+// while it ran.
+type ErrorKind int
+
+const (
+	CompileError ErrorKind = iota
+	ExecutionError
+)
+
+// Error is returned by Run and Eval when the source doesn't compile or hits
+// a runtime error.
+type Error struct {
+	Kind        ErrorKind
+	Diagnostics []*diagnostics.Diagnostic
+}
+
+func (e *Error) Error() string {
+	messages := make([]string, len(e.Diagnostics))
+	for i, d := range e.Diagnostics {
+		messages[i] = d.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// NativeFunc is a Go function callable from Lox. Arguments and results use
+// the value representation described in value.go; Go integers are converted
+// to numbers. A returned error becomes a runtime error at the call site.
+type NativeFunc func(arguments []interface{}) (interface{}, error)
+
+// Interpreter runs Lox source. Globals persist across calls to Run and Eval,
+// so an Interpreter can back a REPL or be primed before running a script.
+type Interpreter struct {
+	options   Options
+	evaluator *Evaluator
+	// vm is set when running on the BytecodeVM engine. The evaluator is
+	// still used for resolving and as the host natives run against.
+	vm *VM
+}
+
+func New(options Options) *Interpreter {
+	if options.Stdin == nil {
+		options.Stdin = os.Stdin
+	}
+	if options.Stdout == nil {
+		options.Stdout = os.Stdout
+	}
+	if options.Stderr == nil {
+		options.Stderr = os.Stderr
+	}
+
+	evaluator := NewEvaluator(&AST{}, NewSymbolTable())
+	// bufio.NewReader hands back a *bufio.Reader unchanged, so a caller
+	// that also reads from Stdin can share its buffer with input().
+	evaluator.stdin = bufio.NewReader(options.Stdin)
+	evaluator.stdout = options.Stdout
+	interpreter := &Interpreter{options: options, evaluator: evaluator}
+	if options.Engine == BytecodeVM {
+		interpreter.vm = NewVM()
+		interpreter.vm.stdout = options.Stdout
+		interpreter.vm.host = evaluator
+		interpreter.vm.trace = options.Trace
+		interpreter.vm.stressGC = options.StressGC
+	}
+	return interpreter
+}
+
+// Run executes source as a program. If ctx is cancelled while it runs, Run
+// stops at the next loop iteration or call and returns ctx.Err().
+func (i *Interpreter) Run(ctx context.Context, source string) error {
+	reporter := i.reporter(source)
+	ast, err := i.parseProgram(reporter, source)
+	if err != nil {
+		return err
+	}
+
+	if i.vm != nil {
+		function, err := NewCompiler(i.vm).Compile(ast.Statements)
+		if err != nil {
+			return i.fail(reporter, CompileError, err)
+		}
+		return i.execute(ctx, reporter, func() error {
+			_, err := i.vm.Interpret(function)
+			return err
+		})
+	}
+
+	i.evaluator.AST = ast
+	return i.execute(ctx, reporter, i.evaluator.Run)
+}
+
+// Compile compiles source as a program for the BytecodeVM engine without
+// running it, for inspection with Disassemble.
+func (i *Interpreter) Compile(source string) (*ObjFunction, error) {
+	if i.vm == nil {
+		return nil, errors.New("lox: Compile requires the BytecodeVM engine")
+	}
+	reporter := i.reporter(source)
+	ast, err := i.parseProgram(reporter, source)
+	if err != nil {
+		return nil, err
+	}
+	function, err := NewCompiler(i.vm).Compile(ast.Statements)
+	if err != nil {
+		return nil, i.fail(reporter, CompileError, err)
+	}
+	return function, nil
+}
+
+// Eval evaluates source as a single expression and returns its value.
+func (i *Interpreter) Eval(ctx context.Context, source string) (interface{}, error) {
+	reporter := i.reporter(source)
+	ast, err := i.parse(reporter, source, (*Parser).Parse)
+	if err != nil {
+		return nil, err
+	}
+	if len(ast.Nodes) != 1 {
+		return nil, i.fail(reporter, CompileError, errors.New("Expect a single expression."))
+	}
+
+	var value interface{}
+	if i.vm != nil {
+		function, err := NewCompiler(i.vm).CompileExpression(ast.Nodes[0])
+		if err != nil {
+			return nil, i.fail(reporter, CompileError, err)
+		}
+		err = i.execute(ctx, reporter, func() error {
+			result, err := i.vm.Interpret(function)
+			value = i.vm.fromVMValue(result)
+			return err
+		})
+		return value, err
+	}
+
+	i.evaluator.AST = ast
+	err = i.execute(ctx, reporter, func() error {
+		results, err := i.evaluator.Evaluate()
+		if err == nil {
+			value = results.([]interface{})[0]
+		}
+		return err
+	})
+	return value, err
+}
+
+// RegisterFunc defines a global native function callable from Lox. Calls
+// with the wrong number of arguments are rejected before fn runs.
+func (i *Interpreter) RegisterFunc(name string, arity int, fn NativeFunc) {
+	i.defineGlobal(name, &NativeFunction{
+		name:  name,
+		arity: arity,
+		fn: func(e *Evaluator, arguments []interface{}) (interface{}, error) {
+			result, err := fn(arguments)
+			if err != nil {
+				return nil, err
+			}
+			return i.toValue(result)
+		},
+	})
+}
+
+// SetGlobal defines or overwrites a global variable. value must be nil, a
+// bool, a Go number, a string or a value previously read from this
+// Interpreter.
+func (i *Interpreter) SetGlobal(name string, value interface{}) error {
+	v, err := i.toValue(value)
+	if err != nil {
+		return err
+	}
+	i.defineGlobal(name, v)
+	return nil
+}
+
+// Global reads a global variable, reporting whether it is defined.
+func (i *Interpreter) Global(name string) (interface{}, bool) {
+	if i.vm != nil {
+		value, ok := i.vm.globals[i.vm.strings[name]]
+		return i.vm.fromVMValue(value), ok
+	}
+	symbol, ok := i.evaluator.symbols.Lookup(name)
+	if !ok {
+		return nil, false
+	}
+	value, ok := i.evaluator.globals.values[symbol]
+	return value, ok
+}
+
+func (i *Interpreter) defineGlobal(name string, value interface{}) {
+	if i.vm != nil {
+		i.vm.defineGlobal(name, i.vm.toVMValue(value))
+		return
+	}
+	i.evaluator.globals.Define(i.evaluator.symbols.Intern(name), value)
+}
+
+func (i *Interpreter) reporter(source string) *diagnostics.Reporter {
+	reporter := diagnostics.NewReporter(i.options.Stderr, source)
+	reporter.Format = i.options.DiagnosticFormat
+	return reporter
+}
+
+func (i *Interpreter) parse(reporter *diagnostics.Reporter, source string, parseFunc func(*Parser) (*AST, error)) (*AST, error) {
+	scanner := NewScanner(source)
+	scanner.Symbols = i.evaluator.symbols
+	tokens := scanner.ScanTokens()
+	parser := NewParser(source, tokens)
+	ast, _ := parseFunc(parser)
+
+	var errs []error
+	for _, err := range scanner.Errors {
+		errs = append(errs, err)
+	}
+	for _, err := range parser.Errors {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, i.fail(reporter, CompileError, errs...)
+	}
+	return ast, nil
+}
+
+// parseProgram parses and resolves source as a sequence of statements.
+func (i *Interpreter) parseProgram(reporter *diagnostics.Reporter, source string) (*AST, error) {
+	ast, err := i.parse(reporter, source, (*Parser).ParseStatements)
+	if err != nil {
+		return nil, err
+	}
+	resolver := NewResolver(i.evaluator)
+	if err := resolver.Resolve(ast.Statements); err != nil {
+		errs := make([]error, len(resolver.Errors))
+		for n, err := range resolver.Errors {
+			errs[n] = err
+		}
+		return nil, i.fail(reporter, CompileError, errs...)
+	}
+	return ast, nil
+}
+
+// execute runs fn with ctx installed on the evaluator. Cancellation is
+// returned as ctx.Err() rather than reported as a Lox runtime error.
+func (i *Interpreter) execute(ctx context.Context, reporter *diagnostics.Reporter, fn func() error) error {
+	i.evaluator.ctx = ctx
+	if i.vm != nil {
+		i.vm.ctx = ctx
+	}
+	defer func() {
+		i.evaluator.ctx = context.Background()
+		if i.vm != nil {
+			i.vm.ctx = context.Background()
+		}
+	}()
+
+	err := fn()
+	if err == nil {
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+		return ctxErr
+	}
+	return i.fail(reporter, ExecutionError, err)
+}
+
+func (i *Interpreter) fail(reporter *diagnostics.Reporter, kind ErrorKind, errs ...error) *Error {
+	loxErr := &Error{Kind: kind}
+	for _, err := range errs {
+		d := diagnostics.From(err)
+		reporter.Report(d)
+		loxErr.Diagnostics = append(loxErr.Diagnostics, d)
+	}
+	return loxErr
+}
+
+// toValue converts a Go value handed to the interpreter into its Lox
+// representation.
+func (i *Interpreter) toValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, bool, float64, string, LoxCallable, *LoxInstance:
+		return v, nil
+	case Object:
+		if i.vm == nil || v.vm != i.vm {
+			return nil, fmt.Errorf("lox: %v belongs to another interpreter", v)
+		}
+		if v.obj.header().freed {
+			return nil, fmt.Errorf("lox: %v has been garbage collected", v)
+		}
+		return v, nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32:
+		return rv.Float(), nil
+	}
+	return nil, fmt.Errorf("lox: unsupported value of type %T", value)
+}
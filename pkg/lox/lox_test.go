@@ -0,0 +1,105 @@
+package lox
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+var engines = []struct {
+	name    string
+	options Options
+}{
+	{"tree", Options{Engine: TreeWalker}},
+	{"vm", Options{Engine: BytecodeVM}},
+	{"vm-stress-gc", Options{Engine: BytecodeVM, StressGC: true}},
+}
+
+func TestGlobalRoundTrip(t *testing.T) {
+	const source = `
+class Point {
+  init(x) { this.x = x; }
+  get() { return this.x; }
+}
+fun add(a, b) { return a + b; }
+var p = Point(42);
+var m = p.get;
+`
+	for _, engine := range engines {
+		t.Run(engine.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			options := engine.options
+			options.Stdout, options.Stderr = &stdout, &stderr
+			interpreter := New(options)
+			ctx := context.Background()
+			if err := interpreter.Run(ctx, source); err != nil {
+				t.Fatalf("Run: %v\n%s", err, stderr.String())
+			}
+
+			for from, to := range map[string]string{"Point": "P", "add": "plus", "p": "q", "m": "n"} {
+				value, ok := interpreter.Global(from)
+				if !ok {
+					t.Fatalf("Global(%q) is not defined", from)
+				}
+				if err := interpreter.SetGlobal(to, value); err != nil {
+					t.Fatalf("SetGlobal(%q): %v", to, err)
+				}
+			}
+			interpreter.RegisterFunc("identity", 1, func(arguments []interface{}) (interface{}, error) {
+				return arguments[0], nil
+			})
+
+			err := interpreter.Run(ctx, `
+print P(1).get();
+print plus(2, 3);
+print q.x;
+print n();
+print identity(q) == q;
+print identity(P);
+`)
+			if err != nil {
+				t.Fatalf("Run: %v\n%s", err, stderr.String())
+			}
+			want := "1\n5\n42\n42\ntrue\nPoint\n"
+			if stdout.String() != want {
+				t.Errorf("stdout = %q, want %q", stdout.String(), want)
+			}
+		})
+	}
+}
+
+func TestSetGlobalRejectsForeignObjects(t *testing.T) {
+	from := New(Options{Engine: BytecodeVM})
+	if err := from.Run(context.Background(), "fun f() {}"); err != nil {
+		t.Fatal(err)
+	}
+	f, _ := from.Global("f")
+
+	for _, engine := range engines {
+		to := New(engine.options)
+		err := to.SetGlobal("f", f)
+		if err == nil || !strings.Contains(err.Error(), "another interpreter") {
+			t.Errorf("%s: SetGlobal(foreign object) = %v, want an error", engine.name, err)
+		}
+	}
+}
+
+func TestSymbolTablePerInterpreter(t *testing.T) {
+	a, b := New(Options{Stdout: io.Discard}), New(Options{Stdout: io.Discard})
+	if err := a.Run(context.Background(), "var onlyInA = 1;"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.evaluator.symbols.Lookup("onlyInA"); ok {
+		t.Error("a name scanned by one interpreter was interned in another")
+	}
+
+	before := len(a.evaluator.symbols.symbols)
+	if _, ok := a.Global("neverDefined"); ok {
+		t.Error("Global found an undefined name")
+	}
+	if after := len(a.evaluator.symbols.symbols); after != before {
+		t.Errorf("Global grew the symbol table from %d to %d entries", before, after)
+	}
+}
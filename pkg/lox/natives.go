@@ -0,0 +1,181 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// NativeFunction is a builtin implemented in Go. Errors returned by fn are
+// reported as runtime errors at the call site.
+type NativeFunction struct {
+	name  string
+	arity int
+	fn    func(e *Evaluator, arguments []interface{}) (interface{}, error)
+}
+
+func (n *NativeFunction) Arity() int {
+	return n.arity
+}
+
+func (n *NativeFunction) Call(e *Evaluator, arguments []interface{}) (interface{}, error) {
+	return n.fn(e, arguments)
+}
+
+func (n *NativeFunction) String() string {
+	return "<native fn>"
+}
+
+// natives is the standard library seeded into every evaluator's globals.
+var natives = []*NativeFunction{
+	{name: "clock", arity: 0, fn: nativeClock},
+	{name: "len", arity: 1, fn: nativeLen},
+	{name: "str", arity: 1, fn: nativeStr},
+	{name: "num", arity: 1, fn: nativeNum},
+	{name: "substr", arity: 3, fn: nativeSubstr},
+	{name: "sqrt", arity: 1, fn: mathNative("sqrt", math.Sqrt)},
+	{name: "floor", arity: 1, fn: mathNative("floor", math.Floor)},
+	{name: "abs", arity: 1, fn: mathNative("abs", math.Abs)},
+	{name: "min", arity: 2, fn: nativeMin},
+	{name: "max", arity: 2, fn: nativeMax},
+	{name: "random", arity: 0, fn: nativeRandom},
+	{name: "input", arity: 0, fn: nativeInput},
+}
+
+func defineNatives(env *Environment, symbols *SymbolTable) {
+	for _, native := range natives {
+		env.Define(symbols.Intern(native.name), native)
+	}
+}
+
+func nativeClock(e *Evaluator, arguments []interface{}) (interface{}, error) {
+	return float64(time.Now().UnixNano()) / float64(time.Second), nil
+}
+
+func nativeLen(e *Evaluator, arguments []interface{}) (interface{}, error) {
+	s, err := stringArg("len", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return float64(utf8.RuneCountInString(s)), nil
+}
+
+func nativeStr(e *Evaluator, arguments []interface{}) (interface{}, error) {
+	return Stringify(arguments[0]), nil
+}
+
+// nativeNum converts a string to a number, returning nil if it doesn't
+// hold one.
+func nativeNum(e *Evaluator, arguments []interface{}) (interface{}, error) {
+	switch v := arguments[0].(type) {
+	case float64:
+		return v, nil
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, nil
+		}
+		return number, nil
+	default:
+		return nil, errors.New("num() argument must be a string or number.")
+	}
+}
+
+// nativeSubstr returns the runes of s from start up to but not including end.
+func nativeSubstr(e *Evaluator, arguments []interface{}) (interface{}, error) {
+	s, err := stringArg("substr", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	start, err := numberArg("substr", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+	end, err := numberArg("substr", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the range while it is still float64: converting a huge end to
+	// int first would wrap around and slip past the bounds check.
+	runes := []rune(s)
+	if start != math.Trunc(start) || end != math.Trunc(end) || start < 0 || end < start || end > float64(len(runes)) {
+		return nil, fmt.Errorf("substr() range [%s, %s) is out of bounds for length %d.", Stringify(start), Stringify(end), len(runes))
+	}
+	return string(runes[int(start):int(end)]), nil
+}
+
+func mathNative(name string, fn func(float64) float64) func(*Evaluator, []interface{}) (interface{}, error) {
+	return func(e *Evaluator, arguments []interface{}) (interface{}, error) {
+		n, err := numberArg(name, arguments, 0)
+		if err != nil {
+			return nil, err
+		}
+		return fn(n), nil
+	}
+}
+
+func nativeMin(e *Evaluator, arguments []interface{}) (interface{}, error) {
+	a, b, err := numberArgs("min", arguments)
+	if err != nil {
+		return nil, err
+	}
+	return math.Min(a, b), nil
+}
+
+func nativeMax(e *Evaluator, arguments []interface{}) (interface{}, error) {
+	a, b, err := numberArgs("max", arguments)
+	if err != nil {
+		return nil, err
+	}
+	return math.Max(a, b), nil
+}
+
+func nativeRandom(e *Evaluator, arguments []interface{}) (interface{}, error) {
+	return rand.Float64(), nil
+}
+
+// nativeInput reads one line from standard input without its line ending,
+// or returns nil once input is exhausted.
+func nativeInput(e *Evaluator, arguments []interface{}) (interface{}, error) {
+	line, err := e.stdin.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return nil, nil
+	}
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("input() failed: %v", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func numberArg(name string, arguments []interface{}, i int) (float64, error) {
+	if n, ok := arguments[i].(float64); ok {
+		return n, nil
+	}
+	return 0, fmt.Errorf("%s() argument %d must be a number.", name, i+1)
+}
+
+func numberArgs(name string, arguments []interface{}) (float64, float64, error) {
+	a, err := numberArg(name, arguments, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	b, err := numberArg(name, arguments, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	return a, b, nil
+}
+
+func stringArg(name string, arguments []interface{}, i int) (string, error) {
+	if s, ok := arguments[i].(string); ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("%s() argument %d must be a string.", name, i+1)
+}
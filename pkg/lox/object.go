@@ -0,0 +1,124 @@
+package lox
+
+import "fmt"
+
+// The bytecode VM represents values like the tree walker (nil, bool and
+// float64 as themselves, natives as *NativeFunction) except that strings,
+// functions, classes and instances are the heap objects below. Every object
+// is allocated through the VM, which tracks it for the collector in gc.go.
+
+// Obj is implemented by every VM heap object.
+type Obj interface {
+	header() *objHeader
+	// blacken marks every object this one refers to.
+	blacken(vm *VM)
+}
+
+// objHeader is embedded in every heap object. It links the object into the
+// VM's list of allocations and holds its mark bit.
+type objHeader struct {
+	marked bool
+	// freed is set when the collector sweeps the object, so an Object
+	// handle that outlived it is rejected instead of resurrecting it.
+	freed bool
+	// size is the number of bytes the object is accounted for.
+	size int
+	next Obj
+}
+
+func (h *objHeader) header() *objHeader {
+	return h
+}
+
+// Object is how the BytecodeVM hands a function, class or instance out
+// through Interpreter.Global, Eval and registered natives. It can be passed
+// back to the interpreter it came from, but only while the program can
+// still reach it: a handle doesn't keep its object alive.
+type Object struct {
+	vm  *VM
+	obj Obj
+}
+
+func (o Object) String() string {
+	return fmt.Sprint(o.obj)
+}
+
+// ObjString is an interned string: the VM never holds two ObjStrings with
+// the same Chars, so strings compare equal exactly when their pointers do.
+type ObjString struct {
+	objHeader
+	Chars string
+}
+
+func (s *ObjString) String() string {
+	return s.Chars
+}
+
+// ObjFunction is a compiled function. The top-level script is a function
+// with an empty Name.
+type ObjFunction struct {
+	objHeader
+	Name         string
+	Arity        int
+	UpvalueCount int
+	Chunk        Chunk
+}
+
+func (f *ObjFunction) String() string {
+	if f.Name == "" {
+		return "<script>"
+	}
+	return fmt.Sprintf("<fn %s>", f.Name)
+}
+
+// ObjUpvalue is a variable captured by a closure. While the variable is
+// still on the VM stack the upvalue is open and refers to it by slot index;
+// once the variable goes out of scope its value is moved into Closed.
+type ObjUpvalue struct {
+	objHeader
+	Slot     int
+	Closed   interface{}
+	IsClosed bool
+	// Next links the VM's open upvalues, sorted by descending Slot.
+	Next *ObjUpvalue
+}
+
+type ObjClosure struct {
+	objHeader
+	Function *ObjFunction
+	Upvalues []*ObjUpvalue
+}
+
+func (c *ObjClosure) String() string {
+	return c.Function.String()
+}
+
+type ObjClass struct {
+	objHeader
+	Name    string
+	Methods map[*ObjString]*ObjClosure
+}
+
+func (c *ObjClass) String() string {
+	return c.Name
+}
+
+type ObjInstance struct {
+	objHeader
+	Class  *ObjClass
+	Fields map[*ObjString]interface{}
+}
+
+func (i *ObjInstance) String() string {
+	return fmt.Sprintf("%s instance", i.Class.Name)
+}
+
+type ObjBoundMethod struct {
+	objHeader
+	Receiver interface{}
+	Method   *ObjClosure
+}
+
+func (b *ObjBoundMethod) String() string {
+	return b.Method.String()
+}
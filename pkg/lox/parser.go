@@ -0,0 +1,936 @@
+package lox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/interpreter-starter-go/pkg/diagnostics"
+)
+
+type Parser struct {
+	Source  string
+	Tokens  []Token
+	Current int
+	Errors  []*ParserError
+}
+
+func NewParser(source string, tokens []Token) *Parser {
+	return &Parser{
+		Source:  source,
+		Tokens:  tokens,
+		Current: 0,
+		Errors:  []*ParserError{},
+	}
+}
+
+type AST struct {
+	Statements []Stmt
+	Nodes      []Expr
+}
+
+// Parse reads a sequence of bare expressions into AST.Nodes. Every syntax
+// error is collected in p.Errors; the first one is returned.
+func (p *Parser) Parse() (*AST, error) {
+	ast := &AST{}
+	for !p.isAtEnd() {
+		expr, err := p.expression()
+		if err != nil {
+			p.recoverFrom(err)
+			continue
+		}
+		ast.Nodes = append(ast.Nodes, expr)
+	}
+	return p.result(ast)
+}
+
+// ParseStatements parses a whole program into AST.Statements, as opposed to
+// Parse which only reads a sequence of bare expressions. Like Parse, it keeps
+// going after a syntax error and collects all of them in p.Errors.
+func (p *Parser) ParseStatements() (*AST, error) {
+	ast := &AST{}
+	for !p.isAtEnd() {
+		stmt, err := p.declaration()
+		if err != nil {
+			p.recoverFrom(err)
+			continue
+		}
+		ast.Statements = append(ast.Statements, stmt)
+	}
+	return p.result(ast)
+}
+
+func (p *Parser) result(ast *AST) (*AST, error) {
+	if len(p.Errors) > 0 {
+		return nil, p.Errors[0]
+	}
+	return ast, nil
+}
+
+// recoverFrom records err and discards tokens until the start of the next
+// statement, so one mistake doesn't cascade into a flood of bogus errors.
+func (p *Parser) recoverFrom(err error) {
+	if parserError, ok := err.(*ParserError); ok {
+		p.Errors = append(p.Errors, parserError)
+	} else {
+		p.Errors = append(p.Errors, &ParserError{Message: err.Error(), Token: p.peek()})
+	}
+	p.synchronize()
+}
+
+func (p *Parser) synchronize() {
+	p.advance()
+	for !p.isAtEnd() {
+		if p.previous().Type == "SEMICOLON" {
+			return
+		}
+		switch p.peek().Type {
+		case "CLASS", "FUN", "VAR", "FOR", "IF", "WHILE", "PRINT", "RETURN":
+			return
+		}
+		p.advance()
+	}
+}
+
+type ParserError struct {
+	Message string
+	Token   Token
+}
+
+func (e *ParserError) Error() string {
+	return e.Diagnostic().Error()
+}
+
+func (e *ParserError) Diagnostic() *diagnostics.Diagnostic {
+	return e.Token.errorAt(e.Message)
+}
+
+func (e *ParserError) ErrorToken() Token {
+	return e.Token
+}
+
+func (p *Parser) declaration() (Stmt, error) {
+	if p.match("CLASS") {
+		return p.classDeclaration()
+	}
+	if p.match("FUN") {
+		function, err := p.function("function")
+		if err != nil {
+			return nil, err
+		}
+		return function, nil
+	}
+	if p.match("VAR") {
+		return p.varDeclaration()
+	}
+	return p.statement()
+}
+
+func (p *Parser) classDeclaration() (Stmt, error) {
+	name, err := p.consume("IDENTIFIER", "Expect class name.")
+	if err != nil {
+		return nil, err
+	}
+
+	var superclass *VariableExpr
+	if p.match("LESS") {
+		superName, err := p.consume("IDENTIFIER", "Expect superclass name.")
+		if err != nil {
+			return nil, err
+		}
+		superclass = &VariableExpr{Name: superName}
+	}
+
+	if _, err := p.consume("LEFT_BRACE", "Expect '{' before class body."); err != nil {
+		return nil, err
+	}
+
+	var methods []FunctionStmt
+	for !p.check("RIGHT_BRACE") && !p.isAtEnd() {
+		method, err := p.function("method")
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, method)
+	}
+
+	if _, err := p.consume("RIGHT_BRACE", "Expect '}' after class body."); err != nil {
+		return nil, err
+	}
+	return ClassStmt{Name: name, Superclass: superclass, Methods: methods}, nil
+}
+
+// function parses the name, parameter list and body of a function or method
+// declaration. kind is only used to word error messages.
+func (p *Parser) function(kind string) (FunctionStmt, error) {
+	name, err := p.consume("IDENTIFIER", "Expect "+kind+" name.")
+	if err != nil {
+		return FunctionStmt{}, err
+	}
+	if _, err := p.consume("LEFT_PAREN", "Expect '(' after "+kind+" name."); err != nil {
+		return FunctionStmt{}, err
+	}
+
+	var params []Token
+	if !p.check("RIGHT_PAREN") {
+		for {
+			if len(params) >= 255 {
+				return FunctionStmt{}, &ParserError{Message: "Can't have more than 255 parameters.", Token: p.peek()}
+			}
+			param, err := p.consume("IDENTIFIER", "Expect parameter name.")
+			if err != nil {
+				return FunctionStmt{}, err
+			}
+			params = append(params, param)
+			if !p.match("COMMA") {
+				break
+			}
+		}
+	}
+	if _, err := p.consume("RIGHT_PAREN", "Expect ')' after parameters."); err != nil {
+		return FunctionStmt{}, err
+	}
+
+	if _, err := p.consume("LEFT_BRACE", "Expect '{' before "+kind+" body."); err != nil {
+		return FunctionStmt{}, err
+	}
+	body, err := p.block()
+	if err != nil {
+		return FunctionStmt{}, err
+	}
+	return FunctionStmt{Name: name, Params: params, Body: body}, nil
+}
+
+func (p *Parser) varDeclaration() (Stmt, error) {
+	name, err := p.consume("IDENTIFIER", "Expect variable name.")
+	if err != nil {
+		return nil, err
+	}
+
+	var initializer Expr
+	if p.match("EQUAL") {
+		initializer, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.consume("SEMICOLON", "Expect ';' after variable declaration."); err != nil {
+		return nil, err
+	}
+	return VarStmt{Name: name, Initializer: initializer}, nil
+}
+
+func (p *Parser) statement() (Stmt, error) {
+	if p.match("FOR") {
+		return p.forStatement()
+	}
+	if p.match("IF") {
+		return p.ifStatement()
+	}
+	if p.match("PRINT") {
+		return p.printStatement()
+	}
+	if p.match("RETURN") {
+		return p.returnStatement()
+	}
+	if p.match("WHILE") {
+		return p.whileStatement()
+	}
+	if p.match("LEFT_BRACE") {
+		statements, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		return BlockStmt{Statements: statements}, nil
+	}
+	return p.expressionStatement()
+}
+
+// forStatement desugars a for loop into an optional initializer followed by
+// a while loop whose body runs the increment after the original body.
+func (p *Parser) forStatement() (Stmt, error) {
+	keyword := p.previous()
+	if _, err := p.consume("LEFT_PAREN", "Expect '(' after 'for'."); err != nil {
+		return nil, err
+	}
+
+	var initializer Stmt
+	var err error
+	switch {
+	case p.match("SEMICOLON"):
+	case p.match("VAR"):
+		initializer, err = p.varDeclaration()
+	default:
+		initializer, err = p.expressionStatement()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var condition Expr
+	if !p.check("SEMICOLON") {
+		condition, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.consume("SEMICOLON", "Expect ';' after loop condition."); err != nil {
+		return nil, err
+	}
+
+	var increment Expr
+	if !p.check("RIGHT_PAREN") {
+		increment, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.consume("RIGHT_PAREN", "Expect ')' after for clauses."); err != nil {
+		return nil, err
+	}
+
+	body, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+
+	if increment != nil {
+		body = BlockStmt{Statements: []Stmt{body, ExpressionStmt{Expression: increment}}}
+	}
+	if condition == nil {
+		condition = Literal{Value: true, token: keyword}
+	}
+	body = WhileStmt{Condition: condition, Body: body}
+	if initializer != nil {
+		body = BlockStmt{Statements: []Stmt{initializer, body}}
+	}
+	return body, nil
+}
+
+func (p *Parser) ifStatement() (Stmt, error) {
+	if _, err := p.consume("LEFT_PAREN", "Expect '(' after 'if'."); err != nil {
+		return nil, err
+	}
+	condition, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consume("RIGHT_PAREN", "Expect ')' after if condition."); err != nil {
+		return nil, err
+	}
+
+	thenBranch, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+	var elseBranch Stmt
+	if p.match("ELSE") {
+		elseBranch, err = p.statement()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return IfStmt{Condition: condition, ThenBranch: thenBranch, ElseBranch: elseBranch}, nil
+}
+
+func (p *Parser) whileStatement() (Stmt, error) {
+	if _, err := p.consume("LEFT_PAREN", "Expect '(' after 'while'."); err != nil {
+		return nil, err
+	}
+	condition, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consume("RIGHT_PAREN", "Expect ')' after condition."); err != nil {
+		return nil, err
+	}
+
+	body, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+	return WhileStmt{Condition: condition, Body: body}, nil
+}
+
+func (p *Parser) block() ([]Stmt, error) {
+	var statements []Stmt
+	for !p.check("RIGHT_BRACE") && !p.isAtEnd() {
+		stmt, err := p.declaration()
+		if err != nil {
+			p.recoverFrom(err)
+			continue
+		}
+		statements = append(statements, stmt)
+	}
+
+	if _, err := p.consume("RIGHT_BRACE", "Expect '}' after block."); err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+func (p *Parser) printStatement() (Stmt, error) {
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consume("SEMICOLON", "Expect ';' after value."); err != nil {
+		return nil, err
+	}
+	return PrintStmt{Expression: value}, nil
+}
+
+func (p *Parser) returnStatement() (Stmt, error) {
+	keyword := p.previous()
+	var value Expr
+	if !p.check("SEMICOLON") {
+		var err error
+		value, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.consume("SEMICOLON", "Expect ';' after return value."); err != nil {
+		return nil, err
+	}
+	return ReturnStmt{Keyword: keyword, Value: value}, nil
+}
+
+func (p *Parser) expressionStatement() (Stmt, error) {
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consume("SEMICOLON", "Expect ';' after expression."); err != nil {
+		return nil, err
+	}
+	return ExpressionStmt{Expression: expr}, nil
+}
+
+func (p *Parser) expression() (Expr, error) {
+	return p.assign()
+}
+
+type Expr interface {
+	expr()
+	String() string
+}
+
+type Stmt interface {
+	stmt()
+}
+
+type PrintStmt struct {
+	Expression Expr
+}
+
+func (p PrintStmt) stmt() {}
+
+type ExpressionStmt struct {
+	Expression Expr
+}
+
+func (e ExpressionStmt) stmt() {}
+
+type VarStmt struct {
+	Name        Token
+	Initializer Expr
+}
+
+func (v VarStmt) stmt() {}
+
+type BlockStmt struct {
+	Statements []Stmt
+}
+
+func (b BlockStmt) stmt() {}
+
+type IfStmt struct {
+	Condition  Expr
+	ThenBranch Stmt
+	ElseBranch Stmt
+}
+
+func (i IfStmt) stmt() {}
+
+type WhileStmt struct {
+	Condition Expr
+	Body      Stmt
+}
+
+func (w WhileStmt) stmt() {}
+
+type FunctionStmt struct {
+	Name   Token
+	Params []Token
+	Body   []Stmt
+}
+
+func (f FunctionStmt) stmt() {}
+
+type ClassStmt struct {
+	Name       Token
+	Superclass *VariableExpr
+	Methods    []FunctionStmt
+}
+
+func (c ClassStmt) stmt() {}
+
+type ReturnStmt struct {
+	Keyword Token
+	Value   Expr
+}
+
+func (r ReturnStmt) stmt() {}
+
+// AssignExpr and VariableExpr are always built as pointers: the resolver keys
+// its scope depths on node identity, and two references to the same name can
+// otherwise compare equal as values.
+type AssignExpr struct {
+	Name  Token
+	Value Expr
+}
+
+func (a AssignExpr) expr() {}
+
+func (a AssignExpr) String() string {
+	return fmt.Sprintf("(%s = %s)", a.Name.Lexeme, a.Value.String())
+}
+
+type VariableExpr struct {
+	Name Token
+}
+
+func (v VariableExpr) expr() {}
+
+func (v VariableExpr) String() string {
+	return v.Name.Lexeme
+}
+
+type GetExpr struct {
+	Object Expr
+	Name   Token
+}
+
+func (g GetExpr) expr() {}
+
+func (g GetExpr) String() string {
+	return fmt.Sprintf("(. %s %s)", g.Object.String(), g.Name.Lexeme)
+}
+
+type SetExpr struct {
+	Object Expr
+	Name   Token
+	Value  Expr
+}
+
+func (s SetExpr) expr() {}
+
+func (s SetExpr) String() string {
+	return fmt.Sprintf("(%s.%s = %s)", s.Object.String(), s.Name.Lexeme, s.Value.String())
+}
+
+// ThisExpr is resolved like a variable, so it is built as a pointer too.
+type ThisExpr struct {
+	Keyword Token
+}
+
+func (t ThisExpr) expr() {}
+
+func (t ThisExpr) String() string {
+	return "this"
+}
+
+// SuperExpr is resolved like a variable, so it is built as a pointer too.
+type SuperExpr struct {
+	Keyword Token
+	Method  Token
+}
+
+func (s SuperExpr) expr() {}
+
+func (s SuperExpr) String() string {
+	return fmt.Sprintf("(super %s)", s.Method.Lexeme)
+}
+
+type BinaryExpr struct {
+	Left     Expr
+	Operator Token
+	Right    Expr
+}
+
+func (b BinaryExpr) expr() {}
+
+func (b BinaryExpr) String() string {
+	return fmt.Sprintf("(%s %s %s)", b.Operator.Lexeme, b.Left.String(), b.Right.String())
+}
+
+type LogicalExpr struct {
+	Left     Expr
+	Operator Token
+	Right    Expr
+}
+
+func (l LogicalExpr) expr() {}
+
+func (l LogicalExpr) String() string {
+	return fmt.Sprintf("(%s %s %s)", l.Operator.Lexeme, l.Left.String(), l.Right.String())
+}
+
+type UnaryExpr struct {
+	Operator Token
+	Right    Expr
+}
+
+func (u UnaryExpr) expr() {}
+
+func (u UnaryExpr) String() string {
+	return fmt.Sprintf("(%s %s)", u.Operator.Lexeme, u.Right.String())
+}
+
+type CallExpr struct {
+	Callee    Expr
+	Paren     Token
+	Arguments []Expr
+}
+
+func (c CallExpr) expr() {}
+
+func (c CallExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString("(call ")
+	sb.WriteString(c.Callee.String())
+	for _, arg := range c.Arguments {
+		sb.WriteString(" ")
+		sb.WriteString(arg.String())
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+// Literal keeps its token only so the bytecode compiler can attribute the
+// instructions it emits to a source line. The field must stay unexported:
+// the JSON AST in cmd/lox walks exported fields by reflection, and the token
+// is not part of the tree.
+type Literal struct {
+	Value interface{}
+	token Token
+}
+
+func (l Literal) expr() {}
+
+func (l Literal) String() string {
+	switch v := l.Value.(type) {
+	case float64:
+		return formatNumber(v)
+	case string:
+		return v
+	case nil:
+		return "nil"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+type Grouping struct {
+	Expression Expr
+}
+
+func (g Grouping) expr() {}
+
+func (g Grouping) String() string {
+	return fmt.Sprintf("(group %s)", g.Expression.String())
+}
+
+func (p *Parser) assign() (Expr, error) {
+	expr, err := p.logicalOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match("EQUAL") {
+		equals := p.previous()
+		value, err := p.assign()
+		if err != nil {
+			return nil, err
+		}
+
+		if variable, ok := expr.(*VariableExpr); ok {
+			return &AssignExpr{
+				Name:  variable.Name,
+				Value: value,
+			}, nil
+		}
+		if get, ok := expr.(GetExpr); ok {
+			return SetExpr{
+				Object: get.Object,
+				Name:   get.Name,
+				Value:  value,
+			}, nil
+		}
+
+		return nil, &ParserError{Message: "Invalid assignment target.", Token: equals}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) logicalOr() (Expr, error) {
+	expr, err := p.logicalAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match("OR") {
+		operator := p.previous()
+		right, err := p.logicalAnd()
+		if err != nil {
+			return nil, err
+		}
+		expr = LogicalExpr{Left: expr, Operator: operator, Right: right}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) logicalAnd() (Expr, error) {
+	expr, err := p.equality()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match("AND") {
+		operator := p.previous()
+		right, err := p.equality()
+		if err != nil {
+			return nil, err
+		}
+		expr = LogicalExpr{Left: expr, Operator: operator, Right: right}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) equality() (Expr, error) {
+	expr, err := p.comparison()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match("BANG_EQUAL", "EQUAL_EQUAL") {
+		operator := p.previous()
+		right, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		expr = BinaryExpr{Left: expr, Operator: operator, Right: right}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) comparison() (Expr, error) {
+	expr, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match("GREATER", "GREATER_EQUAL", "LESS", "LESS_EQUAL") {
+		operator := p.previous()
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		expr = BinaryExpr{Left: expr, Operator: operator, Right: right}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) term() (Expr, error) {
+	expr, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match("MINUS", "PLUS") {
+		operator := p.previous()
+		right, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		expr = BinaryExpr{Left: expr, Operator: operator, Right: right}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) factor() (Expr, error) {
+	expr, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match("SLASH", "STAR") {
+		operator := p.previous()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		expr = BinaryExpr{Left: expr, Operator: operator, Right: right}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) unary() (Expr, error) {
+	if p.match("BANG", "MINUS") {
+		operator := p.previous()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return UnaryExpr{Operator: operator, Right: right}, nil
+	}
+
+	return p.call()
+}
+
+func (p *Parser) call() (Expr, error) {
+	expr, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if p.match("LEFT_PAREN") {
+			expr, err = p.finishCall(expr)
+			if err != nil {
+				return nil, err
+			}
+		} else if p.match("DOT") {
+			name, err := p.consume("IDENTIFIER", "Expect property name after '.'.")
+			if err != nil {
+				return nil, err
+			}
+			expr = GetExpr{Object: expr, Name: name}
+		} else {
+			break
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) finishCall(callee Expr) (Expr, error) {
+	var arguments []Expr
+	if !p.check("RIGHT_PAREN") {
+		for {
+			if len(arguments) >= 255 {
+				return nil, &ParserError{Message: "Can't have more than 255 arguments.", Token: p.peek()}
+			}
+			arg, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			arguments = append(arguments, arg)
+			if !p.match("COMMA") {
+				break
+			}
+		}
+	}
+
+	paren, err := p.consume("RIGHT_PAREN", "Expect ')' after arguments.")
+	if err != nil {
+		return nil, err
+	}
+	return CallExpr{Callee: callee, Paren: paren, Arguments: arguments}, nil
+}
+
+func (p *Parser) primary() (Expr, error) {
+	switch {
+	case p.match("FALSE"):
+		return Literal{Value: false, token: p.previous()}, nil
+	case p.match("TRUE"):
+		return Literal{Value: true, token: p.previous()}, nil
+	case p.match("NIL"):
+		return Literal{Value: nil, token: p.previous()}, nil
+	case p.match("NUMBER"):
+		// The scanner has already normalized hex, binary, exponent and
+		// separator forms into a plain decimal literal.
+		num, err := strconv.ParseFloat(p.previous().Literal.(string), 64)
+		if err != nil {
+			return nil, &ParserError{Message: ("Invalid number format: " + p.previous().Lexeme), Token: p.previous()}
+		}
+
+		return Literal{Value: num, token: p.previous()}, nil
+	case p.match("STRING"):
+		return Literal{Value: p.previous().Literal, token: p.previous()}, nil
+	case p.match("SUPER"):
+		keyword := p.previous()
+		if _, err := p.consume("DOT", "Expect '.' after 'super'."); err != nil {
+			return nil, err
+		}
+		method, err := p.consume("IDENTIFIER", "Expect superclass method name.")
+		if err != nil {
+			return nil, err
+		}
+		return &SuperExpr{Keyword: keyword, Method: method}, nil
+	case p.match("THIS"):
+		return &ThisExpr{Keyword: p.previous()}, nil
+	case p.match("IDENTIFIER"):
+		return &VariableExpr{Name: p.previous()}, nil
+	case p.match("LEFT_PAREN"):
+		expr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.consume("RIGHT_PAREN", "Expect ')' after expression."); err != nil {
+			return nil, err
+		}
+		return Grouping{Expression: expr}, nil
+	default:
+		return nil, &ParserError{Message: "Expect expression.", Token: p.peek()}
+	}
+}
+
+func (p *Parser) match(types ...string) bool {
+	for _, t := range types {
+		if p.check(t) {
+			p.advance()
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Parser) check(t string) bool {
+	return !p.isAtEnd() && p.peek().Type == t
+}
+
+func (p *Parser) advance() Token {
+	if !p.isAtEnd() {
+		p.Current++
+	}
+	return p.previous()
+}
+
+func (p *Parser) isAtEnd() bool {
+	return p.peek().Type == "EOF"
+}
+
+func (p *Parser) peek() Token {
+	return p.Tokens[p.Current]
+}
+
+func (p *Parser) previous() Token {
+	return p.Tokens[p.Current-1]
+}
+
+func (p *Parser) consume(t string, message string) (Token, error) {
+	if p.check(t) {
+		return p.advance(), nil
+	}
+	return Token{}, &ParserError{Message: message, Token: p.peek()}
+}
@@ -0,0 +1,237 @@
+package lox
+
+type functionType int
+
+const (
+	functionNone functionType = iota
+	functionFunction
+	functionInitializer
+	functionMethod
+)
+
+type classType int
+
+const (
+	classNone classType = iota
+	classClass
+	classSubclass
+)
+
+// Resolver walks the AST once before evaluation, telling the evaluator how
+// far away each local variable lives and reporting scope errors that can be
+// caught statically. Like the parser, it keeps going after an error so that
+// every mistake in the file is reported at once.
+type Resolver struct {
+	Errors          []*ParserError
+	evaluator       *Evaluator
+	scopes          []map[string]bool
+	currentFunction functionType
+	currentClass    classType
+}
+
+func NewResolver(evaluator *Evaluator) *Resolver {
+	return &Resolver{
+		evaluator:       evaluator,
+		currentFunction: functionNone,
+		currentClass:    classNone,
+	}
+}
+
+// Resolve resolves a whole program, returning the first error found. All of
+// them are collected in Errors.
+func (r *Resolver) Resolve(statements []Stmt) error {
+	r.resolveStatements(statements)
+	if len(r.Errors) > 0 {
+		return r.Errors[0]
+	}
+	return nil
+}
+
+func (r *Resolver) error(token Token, message string) {
+	r.Errors = append(r.Errors, &ParserError{Message: message, Token: token})
+}
+
+func (r *Resolver) resolveStatements(statements []Stmt) {
+	for _, stmt := range statements {
+		r.resolveStmt(stmt)
+	}
+}
+
+func (r *Resolver) resolveStmt(stmt Stmt) {
+	switch stmt := stmt.(type) {
+	case BlockStmt:
+		r.beginScope()
+		r.resolveStatements(stmt.Statements)
+		r.endScope()
+	case VarStmt:
+		r.declare(stmt.Name)
+		if stmt.Initializer != nil {
+			r.resolveExpr(stmt.Initializer)
+		}
+		r.define(stmt.Name)
+	case FunctionStmt:
+		r.declare(stmt.Name)
+		r.define(stmt.Name)
+		r.resolveFunction(&stmt, functionFunction)
+	case ClassStmt:
+		r.resolveClass(&stmt)
+	case ExpressionStmt:
+		r.resolveExpr(stmt.Expression)
+	case PrintStmt:
+		r.resolveExpr(stmt.Expression)
+	case IfStmt:
+		r.resolveExpr(stmt.Condition)
+		r.resolveStmt(stmt.ThenBranch)
+		if stmt.ElseBranch != nil {
+			r.resolveStmt(stmt.ElseBranch)
+		}
+	case WhileStmt:
+		r.resolveExpr(stmt.Condition)
+		r.resolveStmt(stmt.Body)
+	case ReturnStmt:
+		if r.currentFunction == functionNone {
+			r.error(stmt.Keyword, "Can't return from top-level code.")
+		}
+		if stmt.Value != nil {
+			if r.currentFunction == functionInitializer {
+				r.error(stmt.Keyword, "Can't return a value from an initializer.")
+			}
+			r.resolveExpr(stmt.Value)
+		}
+	}
+}
+
+func (r *Resolver) resolveExpr(expr Expr) {
+	switch expr := expr.(type) {
+	case *VariableExpr:
+		if len(r.scopes) > 0 {
+			if defined, ok := r.scopes[len(r.scopes)-1][expr.Name.Lexeme]; ok && !defined {
+				r.error(expr.Name, "Can't read local variable in its own initializer.")
+			}
+		}
+		r.resolveLocal(expr, expr.Name)
+	case *AssignExpr:
+		r.resolveExpr(expr.Value)
+		r.resolveLocal(expr, expr.Name)
+	case BinaryExpr:
+		r.resolveExpr(expr.Left)
+		r.resolveExpr(expr.Right)
+	case LogicalExpr:
+		r.resolveExpr(expr.Left)
+		r.resolveExpr(expr.Right)
+	case CallExpr:
+		r.resolveExpr(expr.Callee)
+		for _, arg := range expr.Arguments {
+			r.resolveExpr(arg)
+		}
+	case GetExpr:
+		r.resolveExpr(expr.Object)
+	case SetExpr:
+		r.resolveExpr(expr.Value)
+		r.resolveExpr(expr.Object)
+	case *ThisExpr:
+		if r.currentClass == classNone {
+			r.error(expr.Keyword, "Can't use 'this' outside of a class.")
+			return
+		}
+		r.resolveLocal(expr, expr.Keyword)
+	case *SuperExpr:
+		if r.currentClass == classNone {
+			r.error(expr.Keyword, "Can't use 'super' outside of a class.")
+			return
+		}
+		if r.currentClass != classSubclass {
+			r.error(expr.Keyword, "Can't use 'super' in a class with no superclass.")
+			return
+		}
+		r.resolveLocal(expr, expr.Keyword)
+	case Grouping:
+		r.resolveExpr(expr.Expression)
+	case UnaryExpr:
+		r.resolveExpr(expr.Right)
+	}
+}
+
+func (r *Resolver) resolveClass(stmt *ClassStmt) {
+	enclosingClass := r.currentClass
+	r.currentClass = classClass
+	defer func() { r.currentClass = enclosingClass }()
+
+	r.declare(stmt.Name)
+	r.define(stmt.Name)
+
+	if stmt.Superclass != nil {
+		if stmt.Superclass.Name.Lexeme == stmt.Name.Lexeme {
+			r.error(stmt.Superclass.Name, "A class can't inherit from itself.")
+		}
+		r.currentClass = classSubclass
+		r.resolveExpr(stmt.Superclass)
+
+		r.beginScope()
+		defer r.endScope()
+		r.scopes[len(r.scopes)-1]["super"] = true
+	}
+
+	r.beginScope()
+	defer r.endScope()
+	r.scopes[len(r.scopes)-1]["this"] = true
+
+	for i := range stmt.Methods {
+		kind := functionMethod
+		if stmt.Methods[i].Name.Symbol == initSymbol {
+			kind = functionInitializer
+		}
+		r.resolveFunction(&stmt.Methods[i], kind)
+	}
+}
+
+func (r *Resolver) resolveFunction(function *FunctionStmt, kind functionType) {
+	enclosingFunction := r.currentFunction
+	r.currentFunction = kind
+	defer func() { r.currentFunction = enclosingFunction }()
+
+	r.beginScope()
+	defer r.endScope()
+	for _, param := range function.Params {
+		r.declare(param)
+		r.define(param)
+	}
+	r.resolveStatements(function.Body)
+}
+
+func (r *Resolver) beginScope() {
+	r.scopes = append(r.scopes, map[string]bool{})
+}
+
+func (r *Resolver) endScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+func (r *Resolver) declare(name Token) {
+	if len(r.scopes) == 0 {
+		return
+	}
+	scope := r.scopes[len(r.scopes)-1]
+	if _, ok := scope[name.Lexeme]; ok {
+		r.error(name, "Already a variable with this name in this scope.")
+	}
+	scope[name.Lexeme] = false
+}
+
+func (r *Resolver) define(name Token) {
+	if len(r.scopes) == 0 {
+		return
+	}
+	r.scopes[len(r.scopes)-1][name.Lexeme] = true
+}
+
+// resolveLocal records the depth of the innermost scope declaring name.
+// Names not found in any scope are left for the evaluator to treat as globals.
+func (r *Resolver) resolveLocal(expr Expr, name Token) {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if _, ok := r.scopes[i][name.Lexeme]; ok {
+			r.evaluator.Resolve(expr, len(r.scopes)-1-i)
+			return
+		}
+	}
+}
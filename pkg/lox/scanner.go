@@ -0,0 +1,455 @@
+package lox
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/codecrafters-io/interpreter-starter-go/pkg/diagnostics"
+)
+
+type ScannerError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *ScannerError) Error() string {
+	return e.Diagnostic().Error()
+}
+
+func (e *ScannerError) Diagnostic() *diagnostics.Diagnostic {
+	return &diagnostics.Diagnostic{
+		Severity: diagnostics.Error,
+		Line:     e.Line,
+		Column:   e.Column,
+		Length:   1,
+		Message:  e.Message,
+	}
+}
+
+func (s *Scanner) AddError(message string) {
+	s.addErrorAt(s.Start, message)
+}
+
+// addErrorAt reports an error at the byte offset, which may be lines
+// before the scanner's current position, e.g. the opening quote of an
+// unterminated multi-line string.
+func (s *Scanner) addErrorAt(offset int, message string) {
+	s.Errors = append(s.Errors, &ScannerError{Line: s.line(offset), Column: s.column(offset), Message: message})
+}
+
+type Scanner struct {
+	Source  string
+	Tokens  []Token
+	Start   int
+	Current int
+	Line    int
+	Errors  []*ScannerError
+	// Symbols interns the identifiers scanned. Replace it before scanning
+	// to share names with an existing Evaluator.
+	Symbols *SymbolTable
+}
+
+// line returns the 1-based line of the byte at offset.
+func (s *Scanner) line(offset int) int {
+	return strings.Count(s.Source[:offset], "\n") + 1
+}
+
+// column returns the 1-based column, counted in runes, of the byte at offset
+// within its line.
+func (s *Scanner) column(offset int) int {
+	lineStart := strings.LastIndexByte(s.Source[:offset], '\n') + 1
+	return utf8.RuneCountInString(s.Source[lineStart:offset]) + 1
+}
+
+func NewScanner(source string) *Scanner {
+	return &Scanner{
+		Source:  source,
+		Tokens:  []Token{},
+		Start:   0,
+		Current: 0,
+		Line:    1,
+		Errors:  []*ScannerError{},
+		Symbols: NewSymbolTable(),
+	}
+}
+
+func (s *Scanner) ScanTokens() []Token {
+	for s.Current < len(s.Source) {
+		s.Start = s.Current
+		s.ScanToken()
+	}
+	s.Tokens = append(s.Tokens, Token{Type: "EOF", Lexeme: "", Literal: "null", Line: s.Line, Column: s.column(len(s.Source))})
+
+	return s.Tokens
+}
+
+func (s *Scanner) ScanToken() {
+	c := s.Advance()
+	switch c {
+	case '(', ')', '{', '}', ',', '.', '-', '+', ';', '*':
+		s.AddToken(TokenType(c), nil)
+	case '!':
+		s.matchAndAddToken('=', BANG_EQUAL, BANG)
+	case '=':
+		s.matchAndAddToken('=', EQUAL_EQUAL, EQUAL)
+	case '<':
+		s.matchAndAddToken('=', LESS_EQUAL, LESS)
+	case '>':
+		s.matchAndAddToken('=', GREATER_EQUAL, GREATER)
+	case '/':
+		if s.Match('/') {
+			for s.Peek() != '\n' && !s.isAtEnd() {
+				s.Advance()
+			}
+		} else if s.Match('*') {
+			s.scanBlockComment()
+		} else {
+			s.AddToken(SLASH, nil)
+		}
+	case ' ', '\r', '\t':
+		// ignore whitespace
+	case '\n':
+		s.Line++
+	case '"':
+		s.scanString()
+	default:
+		if isDigit(c) {
+			s.scanNumber()
+		} else if isAlpha(c) {
+			s.scanIdentifier()
+		} else {
+			s.AddError(fmt.Sprintf("Unexpected character: %c", c))
+		}
+	}
+}
+
+func (s *Scanner) AddToken(tokenType TokenType, literal interface{}) {
+	text := s.Source[s.Start:s.Current]
+	var literalStr string
+	if literal != nil {
+		literalStr = fmt.Sprintf("%v", literal)
+	} else {
+		literalStr = "null"
+	}
+	tokentypeStr, ok := TokenMap[string(tokenType)]
+	if !ok {
+		tokentypeStr = string(tokenType)
+	}
+	var symbol *Symbol
+	if tokenType == IDENTIFIER || tokenType == THIS || tokenType == SUPER {
+		symbol = s.Symbols.Intern(text)
+	}
+	s.Tokens = append(s.Tokens, Token{
+		Type:    tokentypeStr,
+		Lexeme:  text,
+		Literal: literalStr,
+		Line:    s.Line,
+		Column:  s.column(s.Start),
+		Symbol:  symbol,
+	})
+}
+
+// scanBlockComment skips a /* ... */ comment. Comments nest, so commenting
+// out code that already contains a block comment works as expected.
+func (s *Scanner) scanBlockComment() {
+	depth := 1
+	for depth > 0 {
+		if s.isAtEnd() {
+			s.AddError("Unterminated block comment.")
+			return
+		}
+		switch c := s.Advance(); {
+		case c == '\n':
+			s.Line++
+		case c == '/' && s.Match('*'):
+			depth++
+		case c == '*' && s.Match('/'):
+			depth--
+		}
+	}
+}
+
+// scanString reads a string literal, decoding escape sequences into the
+// token's literal while the lexeme keeps the source text.
+func (s *Scanner) scanString() {
+	var value strings.Builder
+	for s.Peek() != '"' && !s.isAtEnd() {
+		c := s.Advance()
+		switch c {
+		case '\n':
+			s.Line++
+			value.WriteRune(c)
+		case '\\':
+			s.scanEscape(&value)
+		default:
+			value.WriteRune(c)
+		}
+	}
+
+	if s.isAtEnd() {
+		s.AddError("Unterminated string.")
+		return
+	}
+
+	s.Advance()
+	s.AddToken(STRING, value.String())
+}
+
+func (s *Scanner) scanEscape(value *strings.Builder) {
+	start := s.Current - 1
+	if s.isAtEnd() {
+		return
+	}
+
+	c := s.Advance()
+	switch c {
+	case 'n':
+		value.WriteByte('\n')
+	case 't':
+		value.WriteByte('\t')
+	case 'r':
+		value.WriteByte('\r')
+	case '0':
+		value.WriteByte(0)
+	case '\\', '"':
+		value.WriteRune(c)
+	case 'u':
+		s.scanUnicodeEscape(value, start)
+	default:
+		if c == '\n' {
+			s.Line++
+		}
+		s.addErrorAt(start, fmt.Sprintf("Invalid escape sequence '\\%c'.", c))
+	}
+}
+
+// scanUnicodeEscape decodes the {XXXX} part of a \u{XXXX} escape, which may
+// hold one to six hex digits naming any valid code point.
+func (s *Scanner) scanUnicodeEscape(value *strings.Builder, start int) {
+	if !s.Match('{') {
+		s.addErrorAt(start, "Expect '{' after '\\u'.")
+		return
+	}
+	digitsStart := s.Current
+	for isHexDigit(s.Peek()) {
+		s.Advance()
+	}
+	digits := s.Source[digitsStart:s.Current]
+	if !s.Match('}') || len(digits) == 0 || len(digits) > 6 {
+		s.addErrorAt(start, "Invalid Unicode escape sequence.")
+		return
+	}
+
+	code, _ := strconv.ParseUint(digits, 16, 32)
+	if !utf8.ValidRune(rune(code)) {
+		s.addErrorAt(start, fmt.Sprintf("Invalid Unicode code point U+%s.", strings.ToUpper(digits)))
+		return
+	}
+	value.WriteRune(rune(code))
+}
+
+// scanNumber reads a number literal whose first digit has already been
+// consumed. Besides decimals like 123 and 123.45 it accepts exponents (6.02e23),
+// hex (0xFF) and binary (0b1010) integers, and '_' between digits as a
+// separator (1_000_000). Every form is normalized so the token's literal is
+// formatted the same way as a plain decimal with the same value.
+func (s *Scanner) scanNumber() {
+	var number float64
+	var ok bool
+	if s.Source[s.Start] == '0' && (s.Peek() == 'x' || s.Peek() == 'X') {
+		s.Advance()
+		number, ok = s.scanRadixDigits(16, isHexDigit)
+	} else if s.Source[s.Start] == '0' && (s.Peek() == 'b' || s.Peek() == 'B') {
+		s.Advance()
+		number, ok = s.scanRadixDigits(2, isBinaryDigit)
+	} else {
+		number, ok = s.scanDecimal()
+	}
+
+	value := s.Source[s.Start:s.Current]
+	if !ok {
+		s.AddError(fmt.Sprintf("Invalid number: %s", value))
+		return
+	}
+
+	s.AddToken(NUMBER, formatNumber(number))
+}
+
+func (s *Scanner) scanDecimal() (float64, bool) {
+	s.skipDigits(isDigit)
+
+	if s.Peek() == '.' && isDigit(s.PeekNext()) {
+		s.Advance()
+		s.skipDigits(isDigit)
+	}
+
+	if s.Peek() == 'e' || s.Peek() == 'E' {
+		next := s.peekByte(1)
+		if next == '+' || next == '-' {
+			next = s.peekByte(2)
+		}
+		if isDigit(rune(next)) {
+			s.Advance()
+			if s.Peek() == '+' || s.Peek() == '-' {
+				s.Advance()
+			}
+			s.skipDigits(isDigit)
+		}
+	}
+
+	text := s.Source[s.Start:s.Current]
+	if !validSeparators(text, isDigit) {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64)
+	return number, err == nil
+}
+
+func (s *Scanner) scanRadixDigits(base int, isRadixDigit func(rune) bool) (float64, bool) {
+	digitsStart := s.Current
+	s.skipDigits(isRadixDigit)
+	// Reject trailing letters and digits such as 0xFG or 0b102 outright
+	// rather than splitting them into a separate token.
+	if isAlphaNumeric(s.Peek()) {
+		s.skipDigits(isAlphaNumeric)
+		return 0, false
+	}
+
+	digits := s.Source[digitsStart:s.Current]
+	if digits == "" || !validSeparators(digits, isRadixDigit) {
+		return 0, false
+	}
+	n, ok := new(big.Int).SetString(strings.ReplaceAll(digits, "_", ""), base)
+	if !ok {
+		return 0, false
+	}
+	number, _ := new(big.Float).SetInt(n).Float64()
+	return number, true
+}
+
+// skipDigits consumes digits accepted by isValid along with '_' separators.
+func (s *Scanner) skipDigits(isValid func(rune) bool) {
+	for isValid(s.Peek()) || s.Peek() == '_' {
+		s.Advance()
+	}
+}
+
+// validSeparators reports whether every '_' in text sits between two digits
+// accepted by isValid.
+func validSeparators(text string, isValid func(rune) bool) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] != '_' {
+			continue
+		}
+		if i == 0 || i == len(text)-1 || !isValid(rune(text[i-1])) || !isValid(rune(text[i+1])) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Scanner) scanIdentifier() {
+	for isAlphaNumeric(s.Peek()) {
+		s.Advance()
+	}
+
+	text := s.Source[s.Start:s.Current]
+	tokenType, ok := keywords[text]
+	if !ok {
+		tokenType = IDENTIFIER
+	}
+	s.AddToken(tokenType, nil)
+}
+
+func (s *Scanner) matchAndAddToken(expected rune, matchToken, noMatchToken TokenType) {
+	if s.Match(expected) {
+		s.AddToken(matchToken, nil)
+	} else {
+		s.AddToken(noMatchToken, nil)
+	}
+}
+
+// Advance consumes and returns the next rune. Current stays a byte offset
+// into Source, so lexemes can still be sliced out directly.
+func (s *Scanner) Advance() rune {
+	if s.isAtEnd() {
+		return 0
+	}
+	c, size := utf8.DecodeRuneInString(s.Source[s.Current:])
+	s.Current += size
+	return c
+}
+
+func (s *Scanner) Match(expected rune) bool {
+	if s.isAtEnd() {
+		return false
+	}
+	c, size := utf8.DecodeRuneInString(s.Source[s.Current:])
+	if c != expected {
+		return false
+	}
+	s.Current += size
+	return true
+}
+
+func (s *Scanner) Peek() rune {
+	if s.isAtEnd() {
+		return 0
+	}
+	c, _ := utf8.DecodeRuneInString(s.Source[s.Current:])
+	return c
+}
+
+func (s *Scanner) PeekNext() rune {
+	if s.isAtEnd() {
+		return 0
+	}
+	_, size := utf8.DecodeRuneInString(s.Source[s.Current:])
+	if s.Current+size >= len(s.Source) {
+		return 0
+	}
+	c, _ := utf8.DecodeRuneInString(s.Source[s.Current+size:])
+	return c
+}
+
+// peekByte looks n bytes past the current position, for ASCII-only lookahead.
+func (s *Scanner) peekByte(n int) byte {
+	if s.Current+n >= len(s.Source) {
+		return 0
+	}
+	return s.Source[s.Current+n]
+}
+
+func (s *Scanner) isAtEnd() bool {
+	return s.Current >= len(s.Source)
+}
+
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isBinaryDigit(c rune) bool {
+	return c == '0' || c == '1'
+}
+
+func isHexDigit(c rune) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// isAlpha accepts ASCII letters and underscores, plus any Unicode letter so
+// identifiers can be written in non-Latin scripts.
+func isAlpha(c rune) bool {
+	if c >= utf8.RuneSelf {
+		return unicode.IsLetter(c)
+	}
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+}
+
+func isAlphaNumeric(c rune) bool {
+	return isAlpha(c) || isDigit(c)
+}
@@ -0,0 +1,59 @@
+package lox
+
+// Symbol is an interned identifier. Every occurrence of a name scanned
+// against the same SymbolTable shares one Symbol, so environments key on
+// the pointer rather than hashing the name on every lookup.
+type Symbol struct {
+	Name string
+}
+
+func (s *Symbol) String() string {
+	return s.Name
+}
+
+// SymbolTable interns identifiers. Tokens only match names in environments
+// built from the same table, so an Interpreter scans all of its source
+// against one table, and a Scanner used on its own hands its table to the
+// Evaluator that runs what it scanned.
+//
+// A table lives as long as its owner and grows by one entry per distinct
+// identifier scanned into it; strings built at runtime never enter it. It
+// is not safe for concurrent use, like the Interpreter that owns it.
+type SymbolTable struct {
+	symbols map[string]*Symbol
+}
+
+// The keyword-like names the evaluator looks up itself are shared by every
+// table, so code that has no table at hand, like binding "this" for a
+// method, can still use them.
+var (
+	thisSymbol  = &Symbol{Name: "this"}
+	superSymbol = &Symbol{Name: "super"}
+	initSymbol  = &Symbol{Name: "init"}
+)
+
+func NewSymbolTable() *SymbolTable {
+	t := &SymbolTable{symbols: map[string]*Symbol{}}
+	for _, symbol := range []*Symbol{thisSymbol, superSymbol, initSymbol} {
+		t.symbols[symbol.Name] = symbol
+	}
+	return t
+}
+
+// Intern returns the Symbol for name, creating it the first time the name
+// is seen.
+func (t *SymbolTable) Intern(name string) *Symbol {
+	if symbol, ok := t.symbols[name]; ok {
+		return symbol
+	}
+	symbol := &Symbol{Name: name}
+	t.symbols[name] = symbol
+	return symbol
+}
+
+// Lookup returns the Symbol for name without creating one, so asking about
+// a name that was never scanned doesn't grow the table.
+func (t *SymbolTable) Lookup(name string) (*Symbol, bool) {
+	symbol, ok := t.symbols[name]
+	return symbol, ok
+}
@@ -0,0 +1,174 @@
+package lox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// stringEnvironment is Environment as it was before names were interned:
+// every lookup hashes the identifier's text.
+type stringEnvironment struct {
+	values    map[string]interface{}
+	enclosing *stringEnvironment
+}
+
+func (e *stringEnvironment) get(name Token) (interface{}, bool) {
+	if value, ok := e.values[name.Lexeme]; ok {
+		return value, true
+	}
+	if e.enclosing != nil {
+		return e.enclosing.get(name)
+	}
+	return nil, false
+}
+
+// BenchmarkEnvironmentGet looks up a global from four scopes in, as the tree
+// walker does for every global it reads.
+func BenchmarkEnvironmentGet(b *testing.B) {
+	const depth, width = 4, 8
+	scanner := NewScanner("classify")
+	name := scanner.ScanTokens()[0]
+
+	b.Run("symbol", func(b *testing.B) {
+		env := NewEnvironment(nil)
+		defineNatives(env, scanner.Symbols)
+		env.Define(name.Symbol, 1.0)
+		for d := 0; d < depth; d++ {
+			env = NewEnvironment(env)
+			for w := 0; w < width; w++ {
+				env.Define(scanner.Symbols.Intern(fmt.Sprintf("local%d_%d", d, w)), nil)
+			}
+		}
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			if _, err := env.Get(name); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("string", func(b *testing.B) {
+		env := &stringEnvironment{values: map[string]interface{}{}}
+		for _, native := range natives {
+			env.values[native.name] = native
+		}
+		env.values[name.Lexeme] = 1.0
+		for d := 0; d < depth; d++ {
+			env = &stringEnvironment{values: map[string]interface{}{}, enclosing: env}
+			for w := 0; w < width; w++ {
+				env.values[fmt.Sprintf("local%d_%d", d, w)] = nil
+			}
+		}
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			if _, ok := env.get(name); !ok {
+				b.Fatal("undefined")
+			}
+		}
+	})
+}
+
+// BenchmarkStringEquality compares two equal strings built separately, the
+// way the VM did before strings were interned and does now.
+func BenchmarkStringEquality(b *testing.B) {
+	vm := NewVM()
+	x, y := "counter", string([]byte("counter"))
+
+	b.Run("interned", func(b *testing.B) {
+		var a, c interface{} = vm.newString(x), vm.newString(y)
+		for n := 0; n < b.N; n++ {
+			if a != c {
+				b.Fatal("not equal")
+			}
+		}
+	})
+
+	b.Run("string", func(b *testing.B) {
+		var a, c interface{} = x, y
+		for n := 0; n < b.N; n++ {
+			if a != c {
+				b.Fatal("not equal")
+			}
+		}
+	})
+}
+
+// identifiersSource is benchmarks/identifiers.lox cut down to one quick run.
+const identifiersSource = `
+class Counter {
+  init() { this.count = 0; this.label = "counter"; }
+  bump(amount) { this.count = this.count + amount; return this; }
+}
+var alpha = 1;
+var beta = 2;
+var gamma = 3;
+var kind = "alpha";
+fun classify(name) {
+  if (name == "alpha") return alpha;
+  if (name == "beta") return beta;
+  if (name == "gamma") return gamma;
+  return 0;
+}
+fun run(iterations) {
+  var counter = Counter();
+  var total = 0;
+  for (var i = 0; i < iterations; i = i + 1) {
+    var local = alpha + beta + gamma;
+    total = total + local + classify(kind) + classify("gamma");
+    counter.bump(1);
+    if (counter.label == "counter") total = total + 1;
+  }
+  return total + counter.count;
+}
+print run(10000);
+`
+
+func BenchmarkIdentifiers(b *testing.B) {
+	for _, engine := range []struct {
+		name   string
+		engine Engine
+	}{{"tree", TreeWalker}, {"vm", BytecodeVM}} {
+		b.Run(engine.name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				interpreter := New(Options{Engine: engine.engine, Stdout: io.Discard, Stderr: io.Discard})
+				if err := interpreter.Run(context.Background(), identifiersSource); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// longStringsSource compares two long strings that are equal but were built
+// separately, so only interning lets the VM compare them without reading
+// their characters.
+const longStringsSource = `
+fun build() {
+  var s = "x";
+  for (var i = 0; i < 13; i = i + 1) s = s + s;
+  return s;
+}
+var a = build();
+var b = build();
+var same = 0;
+for (var i = 0; i < 100000; i = i + 1) if (a == b) same = same + 1;
+print same;
+`
+
+func BenchmarkLongStringEquality(b *testing.B) {
+	for _, engine := range []struct {
+		name   string
+		engine Engine
+	}{{"tree", TreeWalker}, {"vm", BytecodeVM}} {
+		b.Run(engine.name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				interpreter := New(Options{Engine: engine.engine, Stdout: io.Discard, Stderr: io.Discard})
+				if err := interpreter.Run(context.Background(), longStringsSource); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -1,10 +1,33 @@
-package main
+package lox
 
+import (
+	"unicode/utf8"
+
+	"github.com/codecrafters-io/interpreter-starter-go/pkg/diagnostics"
+)
+
+// Token is a single lexeme. Column is the 1-based column of its first
+// character. Identifiers, this and super carry their interned Symbol.
 type Token struct {
 	Type    string
 	Lexeme  string
 	Literal interface{}
 	Line    int
+	Column  int
+	Symbol  *Symbol
+}
+
+// errorAt builds an error diagnostic pointing at the token.
+func (t Token) errorAt(message string) *diagnostics.Diagnostic {
+	return &diagnostics.Diagnostic{
+		Severity: diagnostics.Error,
+		Line:     t.Line,
+		Column:   t.Column,
+		Length:   utf8.RuneCountInString(t.Lexeme),
+		Token:    t.Lexeme,
+		AtEnd:    t.Type == "EOF",
+		Message:  message,
+	}
 }
 
 type TokenType string
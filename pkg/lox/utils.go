@@ -1,4 +1,4 @@
-package main
+package lox
 
 import (
 	"fmt"
@@ -21,52 +21,30 @@ func formatFloat(value string, number float64) string {
 	}
 
 	if !hasFractionalPart || zeroFraction(value) {
-		return fmt.Sprintf("%.1f", number)
+		return strconv.FormatFloat(number, 'f', -1, 64) + ".0"
 	} else {
 		formattedValue := strconv.FormatFloat(number, 'f', -1, 64)
 		return strings.TrimRight(formattedValue, "0")
 	}
 }
 
-func formatOutput(value interface{}) string {
+// formatNumber renders number the way the scanner prints number literals,
+// whatever form they were written in.
+func formatNumber(number float64) string {
+	return formatFloat(strconv.FormatFloat(number, 'f', -1, 64), number)
+}
+
+// Stringify formats a runtime value the way Lox's print statement does.
+func Stringify(value interface{}) string {
 	switch v := value.(type) {
 	case float64:
 		if float64(int(v)) == v {
 			return fmt.Sprintf("%d", int(v))
 		}
 		return fmt.Sprintf("%.6g", v)
+	case nil:
+		return "nil"
 	default:
 		return fmt.Sprintf("%v", v)
 	}
 }
-
-func isString(value interface{}) bool {
-	_, ok := value.(string)
-	return ok
-}
-
-func isTruthy(value interface{}) bool {
-	if value == nil {
-		return false
-	}
-	if value == "nil" || value == "false" {
-		return false
-	}
-	if value == "true" {
-		return true
-	}
-	if b, ok := value.(bool); ok {
-		return b
-	}
-	return true
-}
-
-func isboolwords(left interface{}, right interface{}) bool {
-	if left == "true" || left == "false" || left == "nil" {
-		return true
-	}
-	if right == "true" || right == "false" || right == "nil" {
-		return true
-	}
-	return false
-}
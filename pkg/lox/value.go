@@ -0,0 +1,46 @@
+package lox
+
+// Lox values are carried around as interface{} holding one of:
+//
+//	nil            Lox nil
+//	bool           true and false
+//	float64        numbers
+//	string         strings
+//	LoxCallable    functions, bound methods and classes
+//	*LoxInstance   class instances
+//
+// Nothing else may appear at runtime. In particular keywords are never
+// encoded as strings, so a string's contents can't change how it behaves.
+//
+// The bytecode VM keeps its own heap objects (object.go) and hands them out
+// of the public API wrapped in an Object; strings come out as Go strings.
+
+// isTruthy follows Lox: nil and false are falsey, everything else is truthy.
+func isTruthy(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	if b, ok := value.(bool); ok {
+		return b
+	}
+	return true
+}
+
+// isEqual compares two values without implicit conversions, so values of
+// different types are never equal. Strings compare by contents: unlike
+// the VM, the tree walker doesn't intern the strings a program builds. It
+// has no collector to drop strings nothing refers to any more, so an
+// intern table would keep every string the program ever built alive.
+func isEqual(a, b interface{}) bool {
+	return a == b
+}
+
+func isString(value interface{}) bool {
+	_, ok := value.(string)
+	return ok
+}
+
+func isNumber(value interface{}) bool {
+	_, ok := value.(float64)
+	return ok
+}
@@ -0,0 +1,518 @@
+package lox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/codecrafters-io/interpreter-starter-go/pkg/diagnostics"
+)
+
+// maxFrames bounds call depth; deeper recursion is reported as a stack
+// overflow rather than growing without limit.
+const maxFrames = 1024
+
+type callFrame struct {
+	closure *ObjClosure
+	ip      int
+	// slots is the stack index of the frame's slot zero.
+	slots int
+}
+
+// VM executes functions produced by the Compiler. Globals persist across
+// calls to Interpret.
+type VM struct {
+	// frames has room for the script's frame on top of maxFrames calls.
+	frames     [maxFrames + 1]callFrame
+	frameCount int
+	stack      []interface{}
+	sp         int
+	globals    map[*ObjString]interface{}
+	// strings interns every string the VM creates; see newString.
+	strings      map[string]*ObjString
+	initString   *ObjString
+	openUpvalues *ObjUpvalue
+	stdout       io.Writer
+	// host is handed to natives, which read input through it.
+	host *Evaluator
+	ctx  context.Context
+	// trace, when set, receives the stack and each instruction as it runs.
+	trace io.Writer
+
+	// objects links every live heap object for the collector in gc.go.
+	objects        Obj
+	grayStack      []Obj
+	bytesAllocated int
+	nextGC         int
+	// stressGC collects on every allocation to flush out missing roots.
+	stressGC bool
+	// compiler is the compiler running against this VM, whose functions are
+	// roots until they are finished.
+	compiler *Compiler
+}
+
+func NewVM() *VM {
+	vm := &VM{
+		stack:   make([]interface{}, 256),
+		globals: map[*ObjString]interface{}{},
+		strings: map[string]*ObjString{},
+		stdout:  os.Stdout,
+		host:    NewEvaluator(&AST{}, NewSymbolTable()),
+		ctx:     context.Background(),
+		nextGC:  initialGCThreshold,
+	}
+	vm.initString = vm.newString("init")
+	for _, native := range natives {
+		vm.defineGlobal(native.name, native)
+	}
+	return vm
+}
+
+// defineGlobal binds a global from Go. value is kept on the stack while the
+// name is allocated so a collection can't free it.
+func (vm *VM) defineGlobal(name string, value interface{}) {
+	vm.push(value)
+	vm.globals[vm.newString(name)] = value
+	vm.pop()
+}
+
+// Interpret runs a compiled top-level function and returns the value it
+// returns, which is nil for scripts.
+func (vm *VM) Interpret(function *ObjFunction) (interface{}, error) {
+	vm.push(function)
+	closure := vm.newClosure(function)
+	vm.pop()
+	vm.push(closure)
+	if err := vm.call(closure, 0, Token{}); err != nil {
+		vm.resetStack()
+		return nil, err
+	}
+	result, err := vm.run()
+	if err != nil {
+		if runtimeErr, ok := err.(*RuntimeError); ok && vm.frameCount > 1 {
+			runtimeErr.Trace = vm.traceback(runtimeErr.Token.Line)
+		}
+		vm.resetStack()
+		return nil, err
+	}
+	return result, nil
+}
+
+// traceback describes the call frames for an error raised on line in the
+// innermost one, matching the tree walker's tracebacks.
+func (vm *VM) traceback(line int) []diagnostics.Frame {
+	frames := make([]diagnostics.Frame, 0, vm.frameCount)
+	for i := vm.frameCount - 1; i >= 0; i-- {
+		frames = append(frames, diagnostics.Frame{Line: line, Function: vm.frames[i].closure.Function.Name})
+		if i > 0 {
+			// The caller's ip is just past its OP_CALL and the argument
+			// count.
+			caller := &vm.frames[i-1]
+			line = caller.closure.Function.Chunk.tokenAt(caller.ip - 2).Line
+		}
+	}
+	return frames
+}
+
+func (vm *VM) run() (interface{}, error) {
+	frame := &vm.frames[vm.frameCount-1]
+	chunk := &frame.closure.Function.Chunk
+
+	readShort := func() int {
+		frame.ip += 2
+		return int(chunk.Code[frame.ip-2])<<8 | int(chunk.Code[frame.ip-1])
+	}
+	readString := func() *ObjString {
+		return chunk.Constants[readShort()].(*ObjString)
+	}
+	fail := func(offset int, format string, args ...interface{}) error {
+		return &RuntimeError{Message: fmt.Sprintf(format, args...), Token: chunk.tokenAt(offset)}
+	}
+
+	for {
+		offset := frame.ip
+		if vm.trace != nil {
+			vm.traceStack(vm.trace)
+			DisassembleInstruction(vm.trace, chunk, offset)
+		}
+		op := OpCode(chunk.Code[frame.ip])
+		frame.ip++
+
+		switch op {
+		case OpConstant:
+			vm.push(chunk.Constants[readShort()])
+		case OpNil:
+			vm.push(nil)
+		case OpTrue:
+			vm.push(true)
+		case OpFalse:
+			vm.push(false)
+		case OpPop:
+			vm.pop()
+
+		case OpGetLocal:
+			slot := int(chunk.Code[frame.ip])
+			frame.ip++
+			vm.push(vm.stack[frame.slots+slot])
+		case OpSetLocal:
+			slot := int(chunk.Code[frame.ip])
+			frame.ip++
+			vm.stack[frame.slots+slot] = vm.peek(0)
+
+		case OpGetGlobal:
+			name := readString()
+			value, ok := vm.globals[name]
+			if !ok {
+				return nil, fail(offset, "Undefined variable '%s'.", name.Chars)
+			}
+			vm.push(value)
+		case OpDefineGlobal:
+			vm.globals[readString()] = vm.pop()
+		case OpSetGlobal:
+			name := readString()
+			if _, ok := vm.globals[name]; !ok {
+				return nil, fail(offset, "Undefined variable '%s'.", name.Chars)
+			}
+			vm.globals[name] = vm.peek(0)
+
+		case OpGetUpvalue:
+			upvalue := frame.closure.Upvalues[chunk.Code[frame.ip]]
+			frame.ip++
+			if upvalue.IsClosed {
+				vm.push(upvalue.Closed)
+			} else {
+				vm.push(vm.stack[upvalue.Slot])
+			}
+		case OpSetUpvalue:
+			upvalue := frame.closure.Upvalues[chunk.Code[frame.ip]]
+			frame.ip++
+			if upvalue.IsClosed {
+				upvalue.Closed = vm.peek(0)
+			} else {
+				vm.stack[upvalue.Slot] = vm.peek(0)
+			}
+
+		case OpGetProperty:
+			name := readString()
+			instance, ok := vm.peek(0).(*ObjInstance)
+			if !ok {
+				return nil, fail(offset, "Only instances have properties.")
+			}
+			if value, ok := instance.Fields[name]; ok {
+				vm.stack[vm.sp-1] = value
+				break
+			}
+			method, ok := instance.Class.Methods[name]
+			if !ok {
+				return nil, fail(offset, "Undefined property '%s'.", name.Chars)
+			}
+			vm.stack[vm.sp-1] = vm.newBoundMethod(instance, method)
+		case OpSetProperty:
+			name := readString()
+			instance, ok := vm.peek(1).(*ObjInstance)
+			if !ok {
+				return nil, fail(offset, "Only instances have fields.")
+			}
+			value := vm.pop()
+			if _, ok := instance.Fields[name]; !ok {
+				vm.grow(instance, fieldSize)
+			}
+			instance.Fields[name] = value
+			vm.stack[vm.sp-1] = value
+		case OpGetSuper:
+			name := readString()
+			superclass := vm.peek(0).(*ObjClass)
+			method, ok := superclass.Methods[name]
+			if !ok {
+				return nil, fail(offset, "Undefined property '%s'.", name.Chars)
+			}
+			bound := vm.newBoundMethod(vm.peek(1), method)
+			vm.pop()
+			vm.stack[vm.sp-1] = bound
+
+		case OpEqual:
+			b := vm.pop()
+			a := vm.pop()
+			vm.push(isEqual(a, b))
+		case OpGreater, OpGreaterEqual, OpLess, OpLessEqual, OpSubtract, OpMultiply, OpDivide:
+			b, bok := vm.peek(0).(float64)
+			a, aok := vm.peek(1).(float64)
+			if !aok || !bok {
+				return nil, fail(offset, "Operands must be numbers.")
+			}
+			vm.sp -= 2
+			switch op {
+			case OpGreater:
+				vm.push(a > b)
+			case OpGreaterEqual:
+				vm.push(a >= b)
+			case OpLess:
+				vm.push(a < b)
+			case OpLessEqual:
+				vm.push(a <= b)
+			case OpSubtract:
+				vm.push(a - b)
+			case OpMultiply:
+				vm.push(a * b)
+			case OpDivide:
+				if b == 0 {
+					return nil, fail(offset, "Division by zero")
+				}
+				vm.push(a / b)
+			}
+		case OpAdd:
+			switch b := vm.peek(0).(type) {
+			case float64:
+				if a, ok := vm.peek(1).(float64); ok {
+					vm.sp -= 2
+					vm.push(a + b)
+					continue
+				}
+			case *ObjString:
+				if a, ok := vm.peek(1).(*ObjString); ok {
+					vm.sp -= 2
+					vm.push(vm.newString(a.Chars + b.Chars))
+					continue
+				}
+			}
+			return nil, fail(offset, "Operands must be two numbers or two strings.")
+		case OpNot:
+			vm.push(!isTruthy(vm.pop()))
+		case OpNegate:
+			value, ok := vm.peek(0).(float64)
+			if !ok {
+				return nil, fail(offset, "Operand must be a number.")
+			}
+			vm.stack[vm.sp-1] = -value
+
+		case OpPrint:
+			fmt.Fprintln(vm.stdout, Stringify(vm.pop()))
+
+		case OpJump:
+			jump := readShort()
+			frame.ip += jump
+		case OpJumpIfFalse:
+			jump := readShort()
+			if !isTruthy(vm.peek(0)) {
+				frame.ip += jump
+			}
+		case OpLoop:
+			jump := readShort()
+			frame.ip -= jump
+			if err := vm.ctx.Err(); err != nil {
+				return nil, err
+			}
+
+		case OpCall:
+			argCount := int(chunk.Code[frame.ip])
+			frame.ip++
+			if err := vm.ctx.Err(); err != nil {
+				return nil, err
+			}
+			if err := vm.callValue(vm.peek(argCount), argCount, chunk.tokenAt(offset)); err != nil {
+				return nil, err
+			}
+			frame = &vm.frames[vm.frameCount-1]
+			chunk = &frame.closure.Function.Chunk
+
+		case OpClosure:
+			function := chunk.Constants[readShort()].(*ObjFunction)
+			closure := vm.newClosure(function)
+			vm.push(closure)
+			for i := range closure.Upvalues {
+				isLocal := chunk.Code[frame.ip] == 1
+				index := int(chunk.Code[frame.ip+1])
+				frame.ip += 2
+				if isLocal {
+					closure.Upvalues[i] = vm.captureUpvalue(frame.slots + index)
+				} else {
+					closure.Upvalues[i] = frame.closure.Upvalues[index]
+				}
+			}
+		case OpCloseUpvalue:
+			vm.closeUpvalues(vm.sp - 1)
+			vm.pop()
+
+		case OpReturn:
+			result := vm.pop()
+			vm.closeUpvalues(frame.slots)
+			vm.frameCount--
+			if vm.frameCount == 0 {
+				vm.sp = 0
+				return result, nil
+			}
+			vm.sp = frame.slots
+			vm.push(result)
+			frame = &vm.frames[vm.frameCount-1]
+			chunk = &frame.closure.Function.Chunk
+
+		case OpClass:
+			vm.push(vm.newClass(readString().Chars))
+		case OpInherit:
+			superclass, ok := vm.peek(1).(*ObjClass)
+			if !ok {
+				return nil, fail(offset, "Superclass must be a class.")
+			}
+			subclass := vm.peek(0).(*ObjClass)
+			for name, method := range superclass.Methods {
+				subclass.Methods[name] = method
+			}
+			vm.grow(subclass, len(superclass.Methods)*fieldSize)
+			vm.pop()
+		case OpMethod:
+			name := readString()
+			class := vm.peek(1).(*ObjClass)
+			if _, ok := class.Methods[name]; !ok {
+				vm.grow(class, fieldSize)
+			}
+			class.Methods[name] = vm.pop().(*ObjClosure)
+
+		default:
+			return nil, fail(offset, "Unknown opcode %d", op)
+		}
+	}
+}
+
+// callValue calls the callee sitting below argCount arguments on the stack.
+// token is the call's closing paren, blamed for any error.
+func (vm *VM) callValue(callee interface{}, argCount int, token Token) error {
+	switch callee := callee.(type) {
+	case *ObjClosure:
+		return vm.call(callee, argCount, token)
+	case *ObjBoundMethod:
+		vm.stack[vm.sp-argCount-1] = callee.Receiver
+		return vm.call(callee.Method, argCount, token)
+	case *ObjClass:
+		vm.stack[vm.sp-argCount-1] = vm.newInstance(callee)
+		if initializer, ok := callee.Methods[vm.initString]; ok {
+			return vm.call(initializer, argCount, token)
+		}
+		if argCount != 0 {
+			return &RuntimeError{Message: fmt.Sprintf("Expected 0 arguments but got %d.", argCount), Token: token}
+		}
+		return nil
+	case *NativeFunction:
+		return vm.callNative(callee, argCount, token)
+	}
+	return &RuntimeError{Message: "Can only call functions and classes.", Token: token}
+}
+
+func (vm *VM) call(closure *ObjClosure, argCount int, token Token) error {
+	if argCount != closure.Function.Arity {
+		return &RuntimeError{
+			Message: fmt.Sprintf("Expected %d arguments but got %d.", closure.Function.Arity, argCount),
+			Token:   token,
+		}
+	}
+	if vm.frameCount == len(vm.frames) {
+		return &RuntimeError{Message: "Stack overflow.", Token: token}
+	}
+	vm.frames[vm.frameCount] = callFrame{closure: closure, slots: vm.sp - argCount - 1}
+	vm.frameCount++
+	return nil
+}
+
+// callNative converts the arguments to the tree walker's representation,
+// which natives are written against, and converts the result back.
+func (vm *VM) callNative(native *NativeFunction, argCount int, token Token) error {
+	if argCount != native.arity {
+		return &RuntimeError{
+			Message: fmt.Sprintf("Expected %d arguments but got %d.", native.arity, argCount),
+			Token:   token,
+		}
+	}
+	arguments := make([]interface{}, argCount)
+	for i := range arguments {
+		arguments[i] = vm.fromVMValue(vm.stack[vm.sp-argCount+i])
+	}
+	result, err := native.fn(vm.host, arguments)
+	if err != nil {
+		if _, ok := err.(*RuntimeError); ok {
+			return err
+		}
+		return &RuntimeError{Message: err.Error(), Token: token}
+	}
+	vm.sp -= argCount + 1
+	vm.push(vm.toVMValue(result))
+	return nil
+}
+
+// captureUpvalue returns the open upvalue for a stack slot, creating it if
+// no closure has captured that slot yet.
+func (vm *VM) captureUpvalue(slot int) *ObjUpvalue {
+	var prev *ObjUpvalue
+	upvalue := vm.openUpvalues
+	for upvalue != nil && upvalue.Slot > slot {
+		prev = upvalue
+		upvalue = upvalue.Next
+	}
+	if upvalue != nil && upvalue.Slot == slot {
+		return upvalue
+	}
+
+	created := vm.newUpvalue(slot, upvalue)
+	if prev == nil {
+		vm.openUpvalues = created
+	} else {
+		prev.Next = created
+	}
+	return created
+}
+
+// closeUpvalues moves every open upvalue at or above last off the stack.
+func (vm *VM) closeUpvalues(last int) {
+	for vm.openUpvalues != nil && vm.openUpvalues.Slot >= last {
+		upvalue := vm.openUpvalues
+		upvalue.Closed = vm.stack[upvalue.Slot]
+		upvalue.IsClosed = true
+		vm.openUpvalues = upvalue.Next
+	}
+}
+
+func (vm *VM) push(value interface{}) {
+	if vm.sp == len(vm.stack) {
+		vm.stack = append(vm.stack, make([]interface{}, len(vm.stack))...)
+	}
+	vm.stack[vm.sp] = value
+	vm.sp++
+}
+
+func (vm *VM) pop() interface{} {
+	vm.sp--
+	return vm.stack[vm.sp]
+}
+
+func (vm *VM) peek(distance int) interface{} {
+	return vm.stack[vm.sp-1-distance]
+}
+
+func (vm *VM) resetStack() {
+	vm.sp = 0
+	vm.frameCount = 0
+	vm.openUpvalues = nil
+}
+
+// fromVMValue converts a VM value for use outside the VM, turning strings
+// back into Go strings and wrapping other heap objects in an Object.
+func (vm *VM) fromVMValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *ObjString:
+		return v.Chars
+	case Obj:
+		return Object{vm: vm, obj: v}
+	}
+	return value
+}
+
+// toVMValue undoes fromVMValue. Objects must already have been checked to
+// belong to this VM.
+func (vm *VM) toVMValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return vm.newString(v)
+	case Object:
+		return v.obj
+	}
+	return value
+}